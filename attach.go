@@ -0,0 +1,101 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+*/
+import "C"
+
+import (
+	"strings"
+)
+
+type AttachOptions struct {
+	// ReadOnly attaches the database in read-only mode.
+	ReadOnly bool
+	// MustExist fails instead of creating the database file when it does
+	// not exist.
+	MustExist bool
+}
+
+// Attach makes the database file at path available to the connection under
+// name, which can then be used to qualify table names in SQL and as the
+// DatabaseName argument of BlobOpen, Checkpoint, Pragmas().Database and
+// similar methods.
+func (d *Connection) Attach(path string, name DatabaseName, opts AttachOptions) error {
+	filename := path
+	if opts.ReadOnly || opts.MustExist {
+		mode := "rw"
+		if opts.ReadOnly {
+			mode = "ro"
+		}
+		filename = "file:" + escapeURIPath(path) + "?mode=" + mode
+	}
+	return d.Exec("ATTACH DATABASE ? AS ?", filename, string(name))
+}
+
+// Detach removes a database added with Attach.
+func (d *Connection) Detach(name DatabaseName) error {
+	return d.Exec("DETACH DATABASE ?", string(name))
+}
+
+// escapeURIPath escapes the characters of path that have a meaning in an
+// SQLite URI filename.
+func escapeURIPath(path string) string {
+	return strings.NewReplacer("%", "%25", "?", "%3f", "#", "%23").Replace(path)
+}
+
+// DatabaseInfo describes a database open on a connection.
+type DatabaseInfo struct {
+	Name DatabaseName
+	// Filename is the absolute path of the database file, empty for
+	// temporary and in-memory databases.
+	Filename string
+	ReadOnly bool
+}
+
+// Databases lists the main, temp and attached databases of the connection.
+func (d *Connection) Databases() ([]DatabaseInfo, error) {
+	rows, err := d.Query("SELECT name FROM pragma_database_list ORDER BY seq")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	result := make([]DatabaseInfo, len(names))
+	for i, name := range names {
+		nameRaw := newDatabaseString(name)
+		result[i] = DatabaseInfo{
+			Name:     DatabaseName(name),
+			Filename: C.GoString(C.sqlite3_db_filename(d.h.ptr, nameRaw.h.ptr)),
+			ReadOnly: C.sqlite3_db_readonly(d.h.ptr, nameRaw.h.ptr) == 1,
+		}
+		nameRaw.Close()
+	}
+	return result, nil
+}
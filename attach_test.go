@@ -0,0 +1,64 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAttach(t *testing.T) {
+	dir := t.TempDir()
+	tenant := filepath.Join(dir, "tenant #1?.db")
+
+	db, err := goliat.Open(filepath.Join(dir, "main.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.Error(t, db.Attach(tenant, "tenant", goliat.AttachOptions{MustExist: true}))
+	assert.NoError(t, db.Attach(tenant, "tenant", goliat.AttachOptions{}))
+	assert.NoError(t, db.Exec("CREATE TABLE tenant.foo (bar BLOB)"))
+	assert.NoError(t, db.Exec("INSERT INTO tenant.foo VALUES (X'0102')"))
+
+	blob, err := db.BlobOpen("tenant", "foo", "bar", 1, goliat.BlobOpenFlagsReadOnly)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, blob.Bytes())
+	blob.Close()
+	assert.NoError(t, db.Detach("tenant"))
+
+	assert.NoError(t, db.Attach(tenant, "archive", goliat.AttachOptions{ReadOnly: true}))
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM archive.foo").Scan(&count))
+	assert.Equal(t, 1, count)
+	assert.Error(t, db.Exec("INSERT INTO archive.foo VALUES (NULL)"))
+
+	databases, err := db.Databases()
+	assert.NoError(t, err)
+	names := make([]goliat.DatabaseName, len(databases))
+	for i, database := range databases {
+		names[i] = database.Name
+	}
+	assert.Equal(t, []goliat.DatabaseName{goliat.DatabaseNameMain, "archive"}, names)
+	assert.Equal(t, filepath.Join(dir, "main.db"), databases[0].Filename)
+	assert.False(t, databases[0].ReadOnly)
+	assert.Equal(t, tenant, databases[1].Filename)
+	assert.True(t, databases[1].ReadOnly)
+
+	assert.NoError(t, db.Detach("archive"))
+	assert.Error(t, db.Detach("archive"))
+}
@@ -0,0 +1,159 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+#include <stdint.h>
+
+extern int goliatAuthorizerCallback(void*, int, char*, char*, char*, char*);
+
+typedef int (*goliat_authorizer)(void*, int, const char*, const char*, const char*, const char*);
+
+static inline int goliat_set_authorizer(sqlite3 *db, uintptr_t handle) {
+	return sqlite3_set_authorizer(db, handle ? (goliat_authorizer)goliatAuthorizerCallback : 0, (void*)handle);
+}
+*/
+import "C"
+
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// AuthAction is the operation being authorized. The meaning of the two
+// arguments passed to an Authorizer depends on the action and is documented
+// next to each constant.
+type AuthAction int
+
+const (
+	AuthActionCreateIndex       AuthAction = C.SQLITE_CREATE_INDEX        // index name, table name
+	AuthActionCreateTable       AuthAction = C.SQLITE_CREATE_TABLE        // table name
+	AuthActionCreateTempIndex   AuthAction = C.SQLITE_CREATE_TEMP_INDEX   // index name, table name
+	AuthActionCreateTempTable   AuthAction = C.SQLITE_CREATE_TEMP_TABLE   // table name
+	AuthActionCreateTempTrigger AuthAction = C.SQLITE_CREATE_TEMP_TRIGGER // trigger name, table name
+	AuthActionCreateTempView    AuthAction = C.SQLITE_CREATE_TEMP_VIEW    // view name
+	AuthActionCreateTrigger     AuthAction = C.SQLITE_CREATE_TRIGGER      // trigger name, table name
+	AuthActionCreateView        AuthAction = C.SQLITE_CREATE_VIEW         // view name
+	AuthActionDelete            AuthAction = C.SQLITE_DELETE              // table name
+	AuthActionDropIndex         AuthAction = C.SQLITE_DROP_INDEX          // index name, table name
+	AuthActionDropTable         AuthAction = C.SQLITE_DROP_TABLE          // table name
+	AuthActionDropTempIndex     AuthAction = C.SQLITE_DROP_TEMP_INDEX     // index name, table name
+	AuthActionDropTempTable     AuthAction = C.SQLITE_DROP_TEMP_TABLE     // table name
+	AuthActionDropTempTrigger   AuthAction = C.SQLITE_DROP_TEMP_TRIGGER   // trigger name, table name
+	AuthActionDropTempView      AuthAction = C.SQLITE_DROP_TEMP_VIEW      // view name
+	AuthActionDropTrigger       AuthAction = C.SQLITE_DROP_TRIGGER        // trigger name, table name
+	AuthActionDropView          AuthAction = C.SQLITE_DROP_VIEW           // view name
+	AuthActionInsert            AuthAction = C.SQLITE_INSERT              // table name
+	AuthActionPragma            AuthAction = C.SQLITE_PRAGMA              // pragma name, first argument
+	AuthActionRead              AuthAction = C.SQLITE_READ                // table name, column name
+	AuthActionSelect            AuthAction = C.SQLITE_SELECT
+	AuthActionTransaction       AuthAction = C.SQLITE_TRANSACTION   // operation
+	AuthActionUpdate            AuthAction = C.SQLITE_UPDATE        // table name, column name
+	AuthActionAttach            AuthAction = C.SQLITE_ATTACH        // filename
+	AuthActionDetach            AuthAction = C.SQLITE_DETACH        // database name
+	AuthActionAlterTable        AuthAction = C.SQLITE_ALTER_TABLE   // database name, table name
+	AuthActionReindex           AuthAction = C.SQLITE_REINDEX       // index name
+	AuthActionAnalyze           AuthAction = C.SQLITE_ANALYZE       // table name
+	AuthActionCreateVTable      AuthAction = C.SQLITE_CREATE_VTABLE // table name, module name
+	AuthActionDropVTable        AuthAction = C.SQLITE_DROP_VTABLE   // table name, module name
+	AuthActionFunction          AuthAction = C.SQLITE_FUNCTION      // function name as second argument
+	AuthActionSavepoint         AuthAction = C.SQLITE_SAVEPOINT     // operation, savepoint name
+	AuthActionRecursive         AuthAction = C.SQLITE_RECURSIVE
+)
+
+// AuthResult is the decision returned by an Authorizer.
+type AuthResult int
+
+const (
+	// AuthResultOK allows the action.
+	AuthResultOK AuthResult = C.SQLITE_OK
+	// AuthResultDeny makes the statement fail to prepare with AUTH.
+	AuthResultDeny AuthResult = C.SQLITE_DENY
+	// AuthResultIgnore prepares the statement but disables the action. Reading
+	// a column yields NULL, while writes are silently skipped.
+	AuthResultIgnore AuthResult = C.SQLITE_IGNORE
+)
+
+// Authorizer is consulted while statements are prepared. dbName is the
+// database the action applies to and trigger is the innermost trigger or view
+// responsible for the access, or empty for top level SQL.
+type Authorizer func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult
+
+// SetAuthorizer installs fn as the connection authorizer. A nil fn removes it.
+// Statements prepared before the call are not affected.
+func (d *Connection) SetAuthorizer(fn Authorizer) error {
+	var handle C.uintptr_t
+	if fn == nil {
+		defer d.h.replaceCallback("authorizer", nil)
+	} else {
+		handle = d.h.replaceCallback("authorizer", fn)
+	}
+	if ec := C.goliat_set_authorizer(d.h.ptr, handle); ec != C.SQLITE_OK {
+		return d.newDatabaseError()
+	}
+	return nil
+}
+
+//export goliatAuthorizerCallback
+func goliatAuthorizerCallback(ctx unsafe.Pointer, action C.int, arg1, arg2, dbName, trigger *C.char) C.int {
+	fn := cgo.Handle(uintptr(ctx)).Value().(Authorizer)
+	return C.int(fn(AuthAction(action), C.GoString(arg1), C.GoString(arg2), C.GoString(dbName), C.GoString(trigger)))
+}
+
+// ReadOnlyAuthorizer only allows SELECT statements, including recursive
+// common table expressions and function calls. Writes, schema changes,
+// ATTACH, PRAGMA and transaction control are denied.
+func ReadOnlyAuthorizer(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+	switch action {
+	case AuthActionSelect, AuthActionRead, AuthActionFunction, AuthActionRecursive:
+		return AuthResultOK
+	default:
+		return AuthResultDeny
+	}
+}
+
+// TableAllowListAuthorizer denies any access to tables not listed in tables
+// and delegates every other decision to next. A nil next allows everything
+// else. The names refer to tables of the main database; tables of temp and
+// attached databases are always denied. SQLite does not report the database
+// of a table referenced without reading any of its columns, as in
+// SELECT count(*), so such references are checked by name only.
+func TableAllowListAuthorizer(tables []string, next Authorizer) Authorizer {
+	allowed := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		allowed[table] = true
+	}
+	return func(action AuthAction, arg1, arg2, dbName, trigger string) AuthResult {
+		var table string
+		switch action {
+		case AuthActionRead, AuthActionInsert, AuthActionUpdate, AuthActionDelete,
+			AuthActionCreateTable, AuthActionCreateTempTable, AuthActionDropTable, AuthActionDropTempTable,
+			AuthActionAnalyze, AuthActionCreateVTable, AuthActionDropVTable:
+			table = arg1
+		case AuthActionCreateIndex, AuthActionCreateTempIndex, AuthActionDropIndex, AuthActionDropTempIndex,
+			AuthActionCreateTrigger, AuthActionCreateTempTrigger, AuthActionDropTrigger, AuthActionDropTempTrigger,
+			AuthActionAlterTable:
+			table = arg2
+		}
+		if table != "" && (!allowed[table] || dbName != "" && dbName != string(DatabaseNameMain)) {
+			return AuthResultDeny
+		}
+		if next == nil {
+			return AuthResultOK
+		}
+		return next(action, arg1, arg2, dbName, trigger)
+	}
+}
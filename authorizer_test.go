@@ -0,0 +1,126 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func assertAuthError(t *testing.T, err error) {
+	t.Helper()
+	var dbErr *goliat.DatabaseError
+	if assert.True(t, errors.As(err, &dbErr)) {
+		assert.Equal(t, goliat.AUTH, dbErr.Code)
+	}
+}
+
+func TestSetAuthorizer(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (bar TEXT, secret TEXT)")
+	assert.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (bar, secret) VALUES (?, ?)", "baz", "qux")
+	assert.NoError(t, err)
+
+	err = db.SetAuthorizer(func(action goliat.AuthAction, arg1, arg2, dbName, trigger string) goliat.AuthResult {
+		if action == goliat.AuthActionRead && arg1 == "foo" && arg2 == "secret" {
+			assert.Equal(t, "main", dbName)
+			return goliat.AuthResultIgnore
+		}
+		return goliat.AuthResultOK
+	})
+	assert.NoError(t, err)
+
+	var bar, secret string
+	err = db.QueryRow("SELECT bar, secret FROM foo").Scan(&bar, &secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "baz", bar)
+	assert.Equal(t, "", secret)
+
+	assert.NoError(t, db.SetAuthorizer(nil))
+	err = db.QueryRow("SELECT secret FROM foo").Scan(&secret)
+	assert.NoError(t, err)
+	assert.Equal(t, "qux", secret)
+}
+
+func TestReadOnlyAuthorizer(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (bar TEXT)")
+	assert.NoError(t, err)
+	assert.NoError(t, db.SetAuthorizer(goliat.ReadOnlyAuthorizer))
+
+	var count int
+	err = db.QueryRow("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 3) SELECT count(*) + length(?) FROM c", "ab").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+
+	assertAuthError(t, db.Exec("INSERT INTO foo (bar) VALUES (?)", "baz"))
+	assertAuthError(t, db.Exec("DROP TABLE foo"))
+	assertAuthError(t, db.Exec("PRAGMA user_version = 1"))
+	assertAuthError(t, db.Exec("ATTACH ':memory:' AS other"))
+}
+
+func TestTableAllowListAuthorizer(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE public (bar TEXT)")
+	assert.NoError(t, err)
+	err = db.Exec("CREATE TABLE secrets (bar TEXT)")
+	assert.NoError(t, err)
+
+	err = db.SetAuthorizer(goliat.TableAllowListAuthorizer([]string{"public"}, goliat.ReadOnlyAuthorizer))
+	assert.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT count(*) FROM public").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, count)
+
+	err = db.QueryRow("SELECT count(*) FROM secrets").Scan(&count)
+	assertAuthError(t, err)
+	err = db.QueryRow("SELECT count(*) FROM public JOIN secrets").Scan(&count)
+	assertAuthError(t, err)
+	assertAuthError(t, db.Exec("INSERT INTO public (bar) VALUES ('baz')"))
+}
+
+func TestTableAllowListAuthorizerOtherDatabases(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Exec("CREATE TABLE public (bar TEXT)"))
+	assert.NoError(t, db.Exec("ATTACH DATABASE ':memory:' AS other"))
+	assert.NoError(t, db.Exec("CREATE TABLE other.public (bar TEXT)"))
+	assert.NoError(t, db.Exec("CREATE TEMP TABLE public (bar TEXT)"))
+
+	assert.NoError(t, db.SetAuthorizer(goliat.TableAllowListAuthorizer([]string{"public"}, nil)))
+
+	var bar string
+	assert.Equal(t, goliat.ErrNoRows, db.QueryRow("SELECT bar FROM main.public").Scan(&bar))
+	assertAuthError(t, db.QueryRow("SELECT bar FROM other.public").Scan(&bar))
+	assertAuthError(t, db.QueryRow("SELECT bar FROM temp.public").Scan(&bar))
+	assertAuthError(t, db.Exec("INSERT INTO other.public (bar) VALUES ('baz')"))
+}
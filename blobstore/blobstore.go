@@ -0,0 +1,405 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package blobstore implements a content-addressed store on top of goliat
+// BLOBs. Objects are split into fixed-size chunks that are deduplicated by
+// their SHA-256 digest and reference counted, so identical content is only
+// stored once.
+package blobstore
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/filcuc/goliat"
+)
+
+// DefaultChunkSize is the chunk size used when Options.ChunkSize is zero.
+const DefaultChunkSize = 256 * 1024
+
+var ErrNotFound = errors.New("blobstore: object not found")
+
+// Digest is the SHA-256 digest of an object's content.
+type Digest [sha256.Size]byte
+
+func (d Digest) String() string {
+	return hex.EncodeToString(d[:])
+}
+
+// ParseDigest parses the hex representation returned by Digest.String.
+func ParseDigest(s string) (Digest, error) {
+	var d Digest
+	raw, err := hex.DecodeString(s)
+	if err != nil {
+		return d, err
+	}
+	if len(raw) != len(d) {
+		return d, fmt.Errorf("invalid digest length %d", len(raw))
+	}
+	copy(d[:], raw)
+	return d, nil
+}
+
+func (d Digest) ToSQLiteValue() (result goliat.BindValue) {
+	result.SetBlob(d[:])
+	return
+}
+
+func (d *Digest) FromSQLiteValue(value goliat.ColumnValue) error {
+	raw, err := value.Blob()
+	if err != nil {
+		return err
+	}
+	if len(raw) != len(d) {
+		return fmt.Errorf("invalid digest length %d", len(raw))
+	}
+	copy(d[:], raw)
+	return nil
+}
+
+type Options struct {
+	// ChunkSize is the maximum size of a stored chunk in bytes.
+	ChunkSize int
+}
+
+// Store is a content-addressed object store. Like the Connection it wraps,
+// a Store must be used by a single goroutine.
+type Store struct {
+	db        *goliat.Connection
+	chunkSize int
+}
+
+// New creates the store tables in db if needed and returns a Store using them.
+func New(db *goliat.Connection, opts Options) (*Store, error) {
+	if opts.ChunkSize < 0 {
+		return nil, fmt.Errorf("invalid chunk size %d", opts.ChunkSize)
+	}
+	if opts.ChunkSize == 0 {
+		opts.ChunkSize = DefaultChunkSize
+	}
+	for _, statement := range []string{
+		"CREATE TABLE IF NOT EXISTS blobstore_chunks (id INTEGER PRIMARY KEY, digest BLOB NOT NULL UNIQUE, refs INTEGER NOT NULL, data BLOB NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS blobstore_objects (digest BLOB PRIMARY KEY, size INTEGER NOT NULL, refs INTEGER NOT NULL)",
+		"CREATE TABLE IF NOT EXISTS blobstore_object_chunks (object BLOB NOT NULL, seq INTEGER NOT NULL, chunk INTEGER NOT NULL, PRIMARY KEY (object, seq))",
+	} {
+		if err := db.Exec(statement); err != nil {
+			return nil, err
+		}
+	}
+	return &Store{db: db, chunkSize: opts.ChunkSize}, nil
+}
+
+// Put stores the content of r and returns its digest. Storing content that is
+// already present only increments its reference count.
+func (s *Store) Put(r io.Reader) (Digest, error) {
+	var digest Digest
+
+	tx, err := s.db.BeginTransaction()
+	if err != nil {
+		return digest, err
+	}
+
+	chunks, size, digest, err := s.putChunks(r)
+	if err != nil {
+		tx.Rollback()
+		return digest, err
+	}
+
+	var refs int64
+	err = s.db.QueryRow("SELECT refs FROM blobstore_objects WHERE digest = ?", digest).Scan(&refs)
+	switch {
+	case err == nil:
+		// The object already exists, so the chunk references taken by
+		// putChunks are discarded together with any chunk they inserted.
+		if err := tx.Rollback(); err != nil {
+			return digest, err
+		}
+		return digest, s.db.Exec("UPDATE blobstore_objects SET refs = refs + 1 WHERE digest = ?", digest)
+	case !errors.Is(err, goliat.ErrNoRows):
+		tx.Rollback()
+		return digest, err
+	}
+
+	if err := s.db.Exec("INSERT INTO blobstore_objects (digest, size, refs) VALUES (?, ?, 1)", digest, size); err != nil {
+		tx.Rollback()
+		return digest, err
+	}
+	stmt, err := s.db.Prepare("INSERT INTO blobstore_object_chunks (object, seq, chunk) VALUES (?, ?, ?)")
+	if err != nil {
+		tx.Rollback()
+		return digest, err
+	}
+	defer stmt.Close()
+	for seq, chunk := range chunks {
+		if err := stmt.Bind(digest, int64(seq), chunk); err != nil {
+			tx.Rollback()
+			return digest, err
+		}
+		if stmt.Step() != goliat.DONE {
+			err := &goliat.DatabaseError{Code: s.db.LastErrorCode(), Message: s.db.LastErrorMessage()}
+			tx.Rollback()
+			return digest, err
+		}
+		if err := stmt.Reset(); err != nil {
+			tx.Rollback()
+			return digest, err
+		}
+	}
+
+	return digest, tx.Commit()
+}
+
+// putChunks splits r into chunks, stores the ones that are not yet present and
+// takes a reference on each of them. It returns the chunk ids in order, the
+// total size and the digest of the whole content.
+func (s *Store) putChunks(r io.Reader) ([]int64, int64, Digest, error) {
+	var digest Digest
+	var chunks []int64
+	var size int64
+
+	hash := sha256.New()
+	buf := make([]byte, s.chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			hash.Write(buf[:n])
+			id, err := s.putChunk(buf[:n])
+			if err != nil {
+				return nil, 0, digest, err
+			}
+			chunks = append(chunks, id)
+			size += int64(n)
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			return nil, 0, digest, err
+		}
+	}
+
+	copy(digest[:], hash.Sum(nil))
+	return chunks, size, digest, nil
+}
+
+func (s *Store) putChunk(data []byte) (int64, error) {
+	chunkDigest := Digest(sha256.Sum256(data))
+
+	var id int64
+	err := s.db.QueryRow("SELECT id FROM blobstore_chunks WHERE digest = ?", chunkDigest).Scan(&id)
+	if err == nil {
+		return id, s.db.Exec("UPDATE blobstore_chunks SET refs = refs + 1 WHERE id = ?", id)
+	}
+	if !errors.Is(err, goliat.ErrNoRows) {
+		return 0, err
+	}
+
+	err = s.db.Exec("INSERT INTO blobstore_chunks (digest, refs, data) VALUES (?, 1, ?)", chunkDigest, goliat.ZeroBlob{Size: uint64(len(data))})
+	if err != nil {
+		return 0, err
+	}
+	id = s.db.LastInsertRowId()
+
+	blob, err := s.db.BlobOpen(goliat.DatabaseNameMain, "blobstore_chunks", "data", id, goliat.BlobOpenFlagsReadWrite)
+	if err != nil {
+		return 0, err
+	}
+	stream := goliat.NewBlobStream(blob)
+	defer stream.Close()
+	if _, err := stream.Write(data); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Size returns the size in bytes of the object with the given digest.
+func (s *Store) Size(digest Digest) (int64, error) {
+	var size int64
+	err := s.db.QueryRow("SELECT size FROM blobstore_objects WHERE digest = ?", digest).Scan(&size)
+	if errors.Is(err, goliat.ErrNoRows) {
+		return 0, ErrNotFound
+	}
+	return size, err
+}
+
+// Release drops one reference to the object with the given digest. Objects
+// without references are removed by GC.
+func (s *Store) Release(digest Digest) error {
+	err := s.db.Exec("UPDATE blobstore_objects SET refs = refs - 1 WHERE digest = ? AND refs > 0", digest)
+	if err != nil {
+		return err
+	}
+	if s.db.Changes() == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// GC removes unreferenced objects and the chunks no longer used by any object.
+// It returns the number of removed objects and chunks.
+func (s *Store) GC() (objects int64, chunks int64, err error) {
+	tx, err := s.db.BeginTransaction()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	steps := []string{
+		`UPDATE blobstore_chunks SET refs = refs - (
+			SELECT count(*) FROM blobstore_object_chunks oc
+			JOIN blobstore_objects o ON o.digest = oc.object
+			WHERE o.refs <= 0 AND oc.chunk = blobstore_chunks.id)
+		WHERE id IN (
+			SELECT oc.chunk FROM blobstore_object_chunks oc
+			JOIN blobstore_objects o ON o.digest = oc.object
+			WHERE o.refs <= 0)`,
+		"DELETE FROM blobstore_object_chunks WHERE object IN (SELECT digest FROM blobstore_objects WHERE refs <= 0)",
+		"DELETE FROM blobstore_objects WHERE refs <= 0",
+		"DELETE FROM blobstore_chunks WHERE refs <= 0",
+	}
+	for i, step := range steps {
+		if err := s.db.Exec(step); err != nil {
+			tx.Rollback()
+			return 0, 0, err
+		}
+		switch i {
+		case 2:
+			objects = s.db.Changes()
+		case 3:
+			chunks = s.db.Changes()
+		}
+	}
+
+	return objects, chunks, tx.Commit()
+}
+
+type chunkRef struct {
+	id     int64
+	offset int64
+	size   int64
+}
+
+// Open returns a reader over the content of the object with the given digest.
+// The reader keeps a single blob handle and moves it between chunks with
+// BlobStream.Reopen.
+func (s *Store) Open(digest Digest) (io.ReadSeekCloser, error) {
+	size, err := s.Size(digest)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`SELECT c.id, length(c.data) FROM blobstore_object_chunks oc
+		JOIN blobstore_chunks c ON c.id = oc.chunk
+		WHERE oc.object = ? ORDER BY oc.seq`, digest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	r := &reader{db: s.db, size: size, current: -1}
+	var offset int64
+	for rows.Next() {
+		var chunk chunkRef
+		if err := rows.Scan(&chunk.id, &chunk.size); err != nil {
+			return nil, err
+		}
+		chunk.offset = offset
+		offset += chunk.size
+		r.chunks = append(r.chunks, chunk)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if offset != size {
+		return nil, fmt.Errorf("blobstore: object %s has %d bytes of chunks, expected %d", digest, offset, size)
+	}
+	return r, nil
+}
+
+type reader struct {
+	db      *goliat.Connection
+	chunks  []chunkRef
+	size    int64
+	offset  int64
+	stream  *goliat.BlobStream
+	current int
+}
+
+func (r *reader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+
+	i := sort.Search(len(r.chunks), func(i int) bool {
+		return r.chunks[i].offset+r.chunks[i].size > r.offset
+	})
+	chunk := r.chunks[i]
+
+	if r.stream == nil {
+		blob, err := r.db.BlobOpen(goliat.DatabaseNameMain, "blobstore_chunks", "data", chunk.id, goliat.BlobOpenFlagsReadOnly)
+		if err != nil {
+			return 0, err
+		}
+		r.stream = goliat.NewBlobStream(blob)
+	} else if r.current != i {
+		if err := r.stream.Reopen(chunk.id); err != nil {
+			return 0, err
+		}
+	}
+	r.current = i
+
+	if _, err := r.stream.Seek(r.offset-chunk.offset, io.SeekStart); err != nil {
+		return 0, err
+	}
+	if remaining := chunk.offset + chunk.size - r.offset; int64(len(p)) > remaining {
+		p = p[:remaining]
+	}
+	n, err := r.stream.Read(p)
+	r.offset += int64(n)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return n, err
+	}
+	return n, nil
+}
+
+func (r *reader) Seek(offset int64, whence int) (int64, error) {
+	var newOffset int64
+	switch whence {
+	case io.SeekStart:
+		newOffset = offset
+	case io.SeekCurrent:
+		newOffset = r.offset + offset
+	case io.SeekEnd:
+		newOffset = r.size + offset
+	default:
+		return 0, fmt.Errorf("invalid whence: %d", whence)
+	}
+	if newOffset < 0 {
+		return 0, fmt.Errorf("seek out of range: %d", newOffset)
+	}
+	r.offset = newOffset
+	return newOffset, nil
+}
+
+func (r *reader) Close() error {
+	if r.stream == nil {
+		return nil
+	}
+	err := r.stream.Close()
+	r.stream = nil
+	return err
+}
@@ -0,0 +1,137 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package blobstore_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/filcuc/goliat/blobstore"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPutAndOpen(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store, err := blobstore.New(db, blobstore.Options{ChunkSize: 16})
+	assert.NoError(t, err)
+
+	expectedData := bytes.Repeat([]byte("0123456789"), 10)
+	digest, err := store.Put(bytes.NewReader(expectedData))
+	assert.NoError(t, err)
+	assert.Equal(t, blobstore.Digest(sha256.Sum256(expectedData)), digest)
+
+	parsed, err := blobstore.ParseDigest(digest.String())
+	assert.NoError(t, err)
+	assert.Equal(t, digest, parsed)
+
+	reader, err := store.Open(digest)
+	assert.NoError(t, err)
+	defer reader.Close()
+
+	actualData, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedData, actualData)
+
+	offset, err := reader.Seek(-15, io.SeekEnd)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(85), offset)
+	actualData, err = io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedData[85:], actualData)
+}
+
+func TestPutDeduplicatesChunks(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store, err := blobstore.New(db, blobstore.Options{ChunkSize: 4})
+	assert.NoError(t, err)
+
+	first, err := store.Put(bytes.NewReader([]byte("aaaabbbbaaaa")))
+	assert.NoError(t, err)
+	second, err := store.Put(bytes.NewReader([]byte("aaaabbbbaaaa")))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	_, err = store.Put(bytes.NewReader([]byte("bbbbcc")))
+	assert.NoError(t, err)
+
+	var chunks int
+	err = db.QueryRow("SELECT COUNT(*) FROM blobstore_chunks").Scan(&chunks)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, chunks)
+
+	var objects int
+	err = db.QueryRow("SELECT COUNT(*) FROM blobstore_objects").Scan(&objects)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, objects)
+}
+
+func TestReleaseAndGC(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store, err := blobstore.New(db, blobstore.Options{ChunkSize: 4})
+	assert.NoError(t, err)
+
+	first, err := store.Put(bytes.NewReader([]byte("aaaabbbb")))
+	assert.NoError(t, err)
+	second, err := store.Put(bytes.NewReader([]byte("aaaacccc")))
+	assert.NoError(t, err)
+
+	assert.NoError(t, store.Release(first))
+	assert.ErrorIs(t, store.Release(first), blobstore.ErrNotFound)
+
+	objects, chunks, err := store.GC()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), objects)
+	assert.Equal(t, int64(1), chunks)
+
+	_, err = store.Open(first)
+	assert.ErrorIs(t, err, blobstore.ErrNotFound)
+
+	reader, err := store.Open(second)
+	assert.NoError(t, err)
+	defer reader.Close()
+	actualData, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("aaaacccc"), actualData)
+}
+
+func TestPutEmpty(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	store, err := blobstore.New(db, blobstore.Options{})
+	assert.NoError(t, err)
+
+	digest, err := store.Put(bytes.NewReader(nil))
+	assert.NoError(t, err)
+
+	reader, err := store.Open(digest)
+	assert.NoError(t, err)
+	defer reader.Close()
+	actualData, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.Empty(t, actualData)
+}
@@ -0,0 +1,242 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+)
+
+type BulkInsertOptions struct {
+	// BatchSize is the number of rows inserted per transaction, 10000 by
+	// default.
+	BatchSize int
+	// MultiRow inserts several rows per statement using a multi-row VALUES
+	// clause, as many as LimitVariableNumber allows.
+	MultiRow bool
+	// ContinueOnError skips rows failing a constraint instead of stopping.
+	// The skipped rows are reported in a *BulkInsertError.
+	ContinueOnError bool
+}
+
+// RowError is the failure of a single row of a bulk insert. Row is the 0-based
+// index of the row in the input sequence.
+type RowError struct {
+	Row int64
+	Err error
+}
+
+func (e *RowError) Error() string {
+	return fmt.Sprintf("row %d: %v", e.Row, e.Err)
+}
+
+func (e *RowError) Unwrap() error {
+	return e.Err
+}
+
+// BulkInsertError lists the rows skipped by a bulk insert with
+// ContinueOnError.
+type BulkInsertError struct {
+	Rows []RowError
+}
+
+func (e *BulkInsertError) Error() string {
+	return fmt.Sprintf("%d rows failed, first %v", len(e.Rows), &e.Rows[0])
+}
+
+func (e *BulkInsertError) Unwrap() []error {
+	result := make([]error, len(e.Rows))
+	for i := range e.Rows {
+		result[i] = &e.Rows[i]
+	}
+	return result
+}
+
+// BulkInsert inserts rows into the given columns of table and returns the
+// number of rows committed. Rows are inserted through one prepared statement,
+// committing every BatchSize rows.
+//
+// A row failing a constraint stops the insert with a *RowError and rolls back
+// the current batch, unless ContinueOnError is set. With MultiRow, the rows of
+// a failing statement are retried one at a time to find the offending ones.
+func (d *Connection) BulkInsert(table string, columns []string, rows iter.Seq[[]any], opts BulkInsertOptions) (int64, error) {
+	if len(columns) == 0 {
+		return 0, errors.New("bulk insert: no columns")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 10000
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	placeholders := "(" + strings.Repeat(", ?", len(columns))[2:] + ")"
+	prefix := fmt.Sprintf("INSERT INTO %s (%s) VALUES ", quoteIdentifier(table), strings.Join(quoted, ", "))
+
+	single, err := d.Prepare(prefix + placeholders)
+	if err != nil {
+		return 0, err
+	}
+	defer single.Close()
+
+	var multi *Statement
+	chunkSize := 1
+	if opts.MultiRow {
+		chunkSize = min(d.SetLimit(LimitVariableNumber, -1)/len(columns), opts.BatchSize)
+	}
+	if chunkSize > 1 {
+		multi, err = d.Prepare(prefix + strings.Repeat(", "+placeholders, chunkSize)[2:])
+		if err != nil {
+			return 0, err
+		}
+		defer multi.Close()
+	}
+
+	var tx *Transaction
+	var committed, pending, index int64
+	var failures []RowError
+	var chunk [][]any
+
+	abort := func(err error) (int64, error) {
+		if tx != nil {
+			err = errors.Join(err, tx.Rollback())
+		}
+		return committed, err
+	}
+
+	// insertRow runs the single row statement for the row at index.
+	insertRow := func(index int64, row []any) error {
+		err := single.Bind(row...)
+		if err == nil {
+			err = stepInsert(single)
+		}
+		single.ClearBindings()
+		if err == nil {
+			pending++
+			return nil
+		}
+		var dbErr *DatabaseError
+		if opts.ContinueOnError && errors.As(err, &dbErr) && dbErr.Code&0xff == CONSTRAINT {
+			failures = append(failures, RowError{Row: index, Err: err})
+			return nil
+		}
+		return &RowError{Row: index, Err: err}
+	}
+
+	// flush inserts the buffered chunk, whose first row is at index start.
+	flush := func(start int64) error {
+		defer func() { chunk = chunk[:0] }()
+		if len(chunk) == chunkSize && multi != nil {
+			var values []any
+			for _, row := range chunk {
+				values = append(values, row...)
+			}
+			err := multi.Bind(values...)
+			if err == nil {
+				err = stepInsert(multi)
+			}
+			multi.ClearBindings()
+			if err == nil {
+				pending += int64(len(chunk))
+				return nil
+			}
+			var dbErr *DatabaseError
+			if !errors.As(err, &dbErr) || dbErr.Code&0xff != CONSTRAINT {
+				return err
+			}
+			// The failed statement was rolled back as a whole, find the
+			// offending rows one at a time.
+		}
+		for i, row := range chunk {
+			if err := insertRow(start+int64(i), row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	commit := func() error {
+		if tx == nil {
+			return nil
+		}
+		err := tx.Commit()
+		tx = nil
+		if err != nil {
+			return err
+		}
+		committed += pending
+		pending = 0
+		return nil
+	}
+
+	for row := range rows {
+		if len(row) != len(columns) {
+			return abort(&RowError{Row: index, Err: fmt.Errorf("%d values for %d columns", len(row), len(columns))})
+		}
+		if tx == nil {
+			if tx, err = d.BeginTransaction(); err != nil {
+				return committed, err
+			}
+		}
+		// Rows buffered for a multi-row statement are copied since the
+		// caller may reuse the slice, a single row is inserted right away.
+		if chunkSize > 1 {
+			row = slices.Clone(row)
+		}
+		chunk = append(chunk, row)
+		index++
+		if len(chunk) == chunkSize {
+			if err := flush(index - int64(len(chunk))); err != nil {
+				return abort(err)
+			}
+		}
+		if index%int64(opts.BatchSize) == 0 {
+			if err := flush(index - int64(len(chunk))); err != nil {
+				return abort(err)
+			}
+			if err := commit(); err != nil {
+				return abort(err)
+			}
+		}
+	}
+	if err := flush(index - int64(len(chunk))); err != nil {
+		return abort(err)
+	}
+	if err := commit(); err != nil {
+		return abort(err)
+	}
+
+	if len(failures) > 0 {
+		return committed, &BulkInsertError{Rows: failures}
+	}
+	return committed, nil
+}
+
+// stepInsert runs a statement that returns no rows and resets it.
+func stepInsert(stmt *Statement) error {
+	ec := stmt.Step()
+	if ec == DONE {
+		return stmt.Reset()
+	}
+	// sqlite3_reset reports the error of the failed step.
+	if err := stmt.Reset(); err != nil {
+		return err
+	}
+	return newDatabaseError(ec, stmt.db.LastErrorMessage())
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+// bulkRows yields n rows, with a duplicated id at each index in duplicates.
+func bulkRows(n int, duplicates ...int) iter.Seq[[]any] {
+	return func(yield func([]any) bool) {
+		row := make([]any, 2)
+		for i := range n {
+			row[0], row[1] = i, fmt.Sprintf("name %d", i)
+			for _, d := range duplicates {
+				if d == i {
+					row[0] = 0
+				}
+			}
+			if !yield(row) {
+				return
+			}
+		}
+	}
+}
+
+func countRows(t *testing.T, db *goliat.Connection, table string) int {
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM "+table).Scan(&count))
+	return count
+}
+
+func TestBulkInsert(t *testing.T) {
+	for _, multiRow := range []bool{false, true} {
+		t.Run(fmt.Sprintf("MultiRow=%v", multiRow), func(t *testing.T) {
+			db, err := goliat.Open(":memory:")
+			assert.NoError(t, err)
+			defer db.Close()
+			assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, name TEXT)"))
+			db.SetLimit(goliat.LimitVariableNumber, 7)
+
+			count, err := db.BulkInsert("foo", []string{"id", "name"}, bulkRows(25), goliat.BulkInsertOptions{BatchSize: 10, MultiRow: multiRow})
+			assert.NoError(t, err)
+			assert.Equal(t, int64(25), count)
+			assert.Equal(t, 25, countRows(t, db, "foo"))
+
+			var name string
+			assert.NoError(t, db.QueryRow("SELECT name FROM foo WHERE id = 24").Scan(&name))
+			assert.Equal(t, "name 24", name)
+		})
+	}
+}
+
+func TestBulkInsertStopsOnError(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, name TEXT)"))
+
+	count, err := db.BulkInsert("foo", []string{"id", "name"}, bulkRows(25, 13), goliat.BulkInsertOptions{BatchSize: 10, MultiRow: true})
+	var rowErr *goliat.RowError
+	assert.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, int64(13), rowErr.Row)
+	assert.Equal(t, int64(10), count)
+	assert.Equal(t, 10, countRows(t, db, "foo"))
+
+	_, err = db.BulkInsert("foo", []string{"id", "name"}, func(yield func([]any) bool) {
+		yield([]any{100})
+	}, goliat.BulkInsertOptions{})
+	assert.True(t, errors.As(err, &rowErr))
+	assert.Equal(t, int64(0), rowErr.Row)
+}
+
+func TestBulkInsertContinueOnError(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, name TEXT)"))
+
+	count, err := db.BulkInsert("foo", []string{"id", "name"}, bulkRows(25, 3, 17), goliat.BulkInsertOptions{
+		BatchSize:       10,
+		MultiRow:        true,
+		ContinueOnError: true,
+	})
+	var bulkErr *goliat.BulkInsertError
+	assert.True(t, errors.As(err, &bulkErr))
+	assert.Len(t, bulkErr.Rows, 2)
+	assert.Equal(t, int64(3), bulkErr.Rows[0].Row)
+	assert.Equal(t, int64(17), bulkErr.Rows[1].Row)
+	assert.Equal(t, int64(23), count)
+	assert.Equal(t, 23, countRows(t, db, "foo"))
+}
@@ -0,0 +1,395 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type CSVHeader int
+
+const (
+	// CSVHeaderAuto treats the first record as a header when its fields
+	// match the columns of the existing table or, for a new table, when
+	// they are distinct non-numeric names.
+	CSVHeaderAuto    = CSVHeader(0)
+	CSVHeaderPresent = CSVHeader(1)
+	CSVHeaderAbsent  = CSVHeader(2)
+)
+
+type CSVImportOptions struct {
+	// Comma is the field delimiter, ',' by default.
+	Comma  rune
+	Header CSVHeader
+	// BatchSize is the number of rows inserted per transaction, 1000 by
+	// default.
+	BatchSize int
+}
+
+// ImportCSV inserts the records read from r into table and returns the number
+// of rows committed. Empty fields are inserted as NULL and the other ones as
+// text, converted by the column affinity like the sqlite3 shell .import
+// command does.
+//
+// If the table does not exist it is created. Without a header its columns are
+// named c1, c2 and so on, and each column is declared INTEGER, REAL or TEXT
+// depending on the values of the first batch. Numbers with leading zeros are
+// considered text.
+//
+// Rows are inserted through a single prepared statement, committing every
+// BatchSize rows. When a row fails the current batch is rolled back and the
+// error reports the line of the record.
+func (d *Connection) ImportCSV(table string, r io.Reader, opts CSVImportOptions) (int64, error) {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+	reader := csv.NewReader(r)
+	if opts.Comma != 0 {
+		reader.Comma = opts.Comma
+	}
+
+	type record struct {
+		fields []string
+		line   int
+	}
+	read := func() (record, error) {
+		fields, err := reader.Read()
+		if err != nil {
+			return record{}, err
+		}
+		line, _ := reader.FieldPos(0)
+		return record{fields: fields, line: line}, nil
+	}
+
+	first, err := read()
+	if err == io.EOF {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	existing, err := d.Pragmas().TableInfo(table)
+	if err != nil {
+		return 0, err
+	}
+
+	header := opts.Header == CSVHeaderPresent
+	if opts.Header == CSVHeaderAuto {
+		if len(existing) > 0 {
+			header = matchesColumns(first.fields, existing)
+		} else {
+			header = looksLikeHeader(first.fields)
+		}
+	}
+
+	var columns []string
+	var batch []record
+	switch {
+	case header:
+		columns = first.fields
+	case len(existing) > 0:
+		if len(first.fields) > len(existing) {
+			return 0, fmt.Errorf("line %d: %d fields for %d columns", first.line, len(first.fields), len(existing))
+		}
+		for _, column := range existing[:len(first.fields)] {
+			columns = append(columns, column.Name)
+		}
+		batch = append(batch, first)
+	default:
+		for i := range first.fields {
+			columns = append(columns, fmt.Sprintf("c%d", i+1))
+		}
+		batch = append(batch, first)
+	}
+
+	// Fill the first batch, which is also the sample used for inferring the
+	// types of a new table.
+	eof := false
+	for len(batch) < opts.BatchSize {
+		rec, err := read()
+		if err == io.EOF {
+			eof = true
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+		batch = append(batch, rec)
+	}
+
+	if len(existing) == 0 {
+		definitions := make([]string, len(columns))
+		for i, column := range columns {
+			var values []string
+			for _, rec := range batch {
+				values = append(values, rec.fields[i])
+			}
+			definitions[i] = quoteIdentifier(column) + " " + inferColumnType(values)
+		}
+		err := d.Exec(fmt.Sprintf("CREATE TABLE %s (%s)", quoteIdentifier(table), strings.Join(definitions, ", ")))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+	}
+	stmt, err := d.Prepare(fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)", quoteIdentifier(table),
+		strings.Join(quoted, ", "), strings.Repeat(", ?", len(columns))[2:]))
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	insert := func(rec record) error {
+		for i, field := range rec.fields {
+			var value any
+			if field != "" {
+				value = field
+			}
+			if err := stmt.BindValue(i+1, value); err != nil {
+				return err
+			}
+		}
+		ec := stmt.Step()
+		stmt.Reset()
+		if ec != DONE {
+			return newDatabaseError(ec, d.LastErrorMessage())
+		}
+		return nil
+	}
+
+	var committed int64
+	for len(batch) > 0 {
+		tx, err := d.BeginTransaction()
+		if err != nil {
+			return committed, err
+		}
+		for _, rec := range batch {
+			if err := insert(rec); err != nil {
+				return committed, errors.Join(fmt.Errorf("line %d: %w", rec.line, err), tx.Rollback())
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return committed, err
+		}
+		committed += int64(len(batch))
+
+		batch = batch[:0]
+		for !eof && len(batch) < opts.BatchSize {
+			rec, err := read()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return committed, err
+			}
+			batch = append(batch, rec)
+		}
+	}
+	return committed, nil
+}
+
+func matchesColumns(fields []string, columns []ColumnInfo) bool {
+	for _, field := range fields {
+		found := false
+		for _, column := range columns {
+			if strings.EqualFold(field, column.Name) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func looksLikeHeader(fields []string) bool {
+	seen := make(map[string]bool)
+	for _, field := range fields {
+		name := strings.ToLower(field)
+		if name == "" || seen[name] || isCSVInteger(field) || isCSVReal(field) {
+			return false
+		}
+		seen[name] = true
+	}
+	return true
+}
+
+func inferColumnType(values []string) string {
+	result := "INTEGER"
+	seen := false
+	for _, value := range values {
+		if value == "" {
+			continue
+		}
+		seen = true
+		switch {
+		case isCSVInteger(value):
+		case isCSVReal(value):
+			result = "REAL"
+		default:
+			return "TEXT"
+		}
+	}
+	if !seen {
+		return "TEXT"
+	}
+	return result
+}
+
+// isCSVInteger reports whether s is a decimal integer fitting in 64 bits and
+// without leading zeros.
+func isCSVInteger(s string) bool {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || digits == "" || (len(digits) > 1 && digits[0] == '0') {
+		return false
+	}
+	_, err := strconv.ParseInt(s, 10, 64)
+	return err == nil
+}
+
+// isCSVReal reports whether s is a decimal floating point number without
+// leading zeros.
+func isCSVReal(s string) bool {
+	digits := strings.TrimLeft(s, "+-")
+	if len(s)-len(digits) > 1 || digits == "" {
+		return false
+	}
+	integer, _, _ := strings.Cut(strings.ToLower(digits), "e")
+	integer, _, _ = strings.Cut(integer, ".")
+	if len(integer) > 1 && integer[0] == '0' {
+		return false
+	}
+	for _, c := range digits {
+		if !strings.ContainsRune("0123456789.eE+-", c) {
+			return false
+		}
+	}
+	_, err := strconv.ParseFloat(s, 64)
+	return err == nil
+}
+
+type ExportFormat int
+
+const (
+	ExportFormatCSV = ExportFormat(0)
+	ExportFormatTSV = ExportFormat(1)
+	// ExportFormatJSONLines writes one JSON object per row, keyed by column
+	// name. BLOBs are encoded in base64.
+	ExportFormatJSONLines = ExportFormat(2)
+)
+
+// ExportQuery runs sql and writes its rows to w. CSV and TSV output starts
+// with a header of column names and writes NULL as an empty field.
+func (d *Connection) ExportQuery(w io.Writer, format ExportFormat, sql string, args ...any) error {
+	rows, err := d.Query(sql, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	columns := rows.Columns()
+
+	switch format {
+	case ExportFormatCSV, ExportFormatTSV:
+		writer := csv.NewWriter(w)
+		if format == ExportFormatTSV {
+			writer.Comma = '\t'
+		}
+		if err := writer.Write(columns); err != nil {
+			return err
+		}
+		record := make([]string, len(columns))
+		for rows.Next() {
+			for i := range record {
+				record[i] = rows.stmt.columnText(i)
+			}
+			if err := writer.Write(record); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		if err := writer.Error(); err != nil {
+			return err
+		}
+
+	case ExportFormatJSONLines:
+		out := bufio.NewWriter(w)
+		keys := make([][]byte, len(columns))
+		for i, column := range columns {
+			if keys[i], err = json.Marshal(column); err != nil {
+				return err
+			}
+		}
+		for rows.Next() {
+			out.WriteByte('{')
+			for i := range columns {
+				if i > 0 {
+					out.WriteByte(',')
+				}
+				out.Write(keys[i])
+				out.WriteByte(':')
+				value, err := json.Marshal(rows.stmt.columnAny(i))
+				if err != nil {
+					return fmt.Errorf("column %s: %w", columns[i], err)
+				}
+				out.Write(value)
+			}
+			out.WriteString("}\n")
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown export format %d", format)
+	}
+	return rows.Err()
+}
+
+// columnAny returns column i as the Go type matching its storage class.
+func (stmt *Statement) columnAny(i int) any {
+	value := ColumnValue{datatype: stmt.columnDatatype(i), stmt: stmt, index: i}
+	switch {
+	case value.IsInteger():
+		v, _ := value.Integer()
+		return v
+	case value.IsFloat():
+		v, _ := value.ToFloat()
+		return v
+	case value.IsText():
+		return stmt.columnText(i)
+	case value.IsBlob():
+		v, _ := value.Blob()
+		if v == nil {
+			v = []byte{}
+		}
+		return v
+	}
+	return nil
+}
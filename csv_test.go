@@ -0,0 +1,96 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImportCSVCreatesTable(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	data := "id,zip,price,name\n1,00123,1.5,foo\n2,00456,2,\"bar, baz\"\n3,,,\n"
+	count, err := db.ImportCSV("vendors", strings.NewReader(data), goliat.CSVImportOptions{BatchSize: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), count)
+
+	columns, err := db.Pragmas().TableInfo("vendors")
+	assert.NoError(t, err)
+	var types []string
+	for _, column := range columns {
+		types = append(types, column.Name+" "+column.Type)
+	}
+	assert.Equal(t, []string{"id INTEGER", "zip TEXT", "price REAL", "name TEXT"}, types)
+
+	var zip, name string
+	var price float64
+	assert.NoError(t, db.QueryRow("SELECT zip, price, name FROM vendors WHERE id = 2").Scan(&zip, &price, &name))
+	assert.Equal(t, "00456", zip)
+	assert.Equal(t, 2.0, price)
+	assert.Equal(t, "bar, baz", name)
+
+	var nulls int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM vendors WHERE zip IS NULL AND name IS NULL").Scan(&nulls))
+	assert.Equal(t, 1, nulls)
+}
+
+func TestImportCSVExistingTable(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT NOT NULL)"))
+
+	count, err := db.ImportCSV("foo", strings.NewReader("1\ta\n2\tb\n"), goliat.CSVImportOptions{Comma: '\t'})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	count, err = db.ImportCSV("foo", strings.NewReader("BAR,ID\nc,3\nd,4\n,5\ne,6\n"), goliat.CSVImportOptions{BatchSize: 2})
+	assert.ErrorContains(t, err, "line 4")
+	assert.Equal(t, int64(2), count)
+
+	var total int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&total))
+	assert.Equal(t, 4, total)
+}
+
+func TestExportQuery(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.ExecScript(`CREATE TABLE foo (id INTEGER, name TEXT, score REAL, data BLOB);
+		INSERT INTO foo VALUES (1, 'a,b', 1.5, X'0102'), (2, NULL, NULL, NULL);`))
+
+	var out bytes.Buffer
+	assert.NoError(t, db.ExportQuery(&out, goliat.ExportFormatCSV, "SELECT id, name, score FROM foo ORDER BY id"))
+	assert.Equal(t, "id,name,score\n1,\"a,b\",1.5\n2,,\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, db.ExportQuery(&out, goliat.ExportFormatTSV, "SELECT id, name FROM foo WHERE id = ?", 1))
+	assert.Equal(t, "id\tname\n1\ta,b\n", out.String())
+
+	out.Reset()
+	assert.NoError(t, db.ExportQuery(&out, goliat.ExportFormatJSONLines, "SELECT * FROM foo ORDER BY id"))
+	assert.Equal(t, `{"id":1,"name":"a,b","score":1.5,"data":"AQI="}`+"\n"+
+		`{"id":2,"name":null,"score":null,"data":null}`+"\n", out.String())
+
+	assert.Error(t, db.ExportQuery(&out, goliat.ExportFormat(42), "SELECT 1"))
+}
@@ -0,0 +1,231 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+*/
+import "C"
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+type DumpOptions struct {
+	// Tables restricts the dump to the named tables and their indexes and
+	// triggers. Views and sqlite_sequence are only dumped when Tables is
+	// empty.
+	Tables []string
+	// SchemaOnly skips the table contents.
+	SchemaOnly bool
+	// DataOnly skips the CREATE statements.
+	DataOnly bool
+}
+
+// Dump writes the main database as an SQL script in the format of the sqlite3
+// shell .dump command. Values are written with the SQL quote() function, so
+// BLOBs become hex literals and floating point values round-trip exactly.
+func (d *Connection) Dump(w io.Writer, opts DumpOptions) error {
+	if opts.SchemaOnly && opts.DataOnly {
+		return errors.New("dump: SchemaOnly and DataOnly are mutually exclusive")
+	}
+
+	type object struct {
+		kind, name, table, sql string
+	}
+	rows, err := d.Query(`SELECT type, name, tbl_name, sql FROM main.sqlite_schema
+		WHERE sql IS NOT NULL AND type IN ('table', 'index', 'view', 'trigger') ORDER BY rowid`)
+	if err != nil {
+		return err
+	}
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.kind, &o.name, &o.table, &o.sql); err != nil {
+			rows.Close()
+			return err
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	selected := func(table string) bool {
+		return len(opts.Tables) == 0 || slices.Contains(opts.Tables, table)
+	}
+
+	out := bufio.NewWriter(w)
+	fmt.Fprintln(out, "PRAGMA foreign_keys=OFF;")
+	fmt.Fprintln(out, "BEGIN TRANSACTION;")
+
+	hasSequence := false
+	for _, o := range objects {
+		if o.kind != "table" || !selected(o.name) {
+			continue
+		}
+		if o.name == "sqlite_sequence" {
+			hasSequence = true
+			continue
+		}
+		if strings.HasPrefix(o.name, "sqlite_") {
+			continue
+		}
+
+		var kind string
+		err := d.QueryRow("SELECT type FROM pragma_table_list WHERE name = ? AND schema = 'main'", o.name).Scan(&kind)
+		if err != nil {
+			return err
+		}
+		// Shadow tables are created together with their virtual table, only
+		// their content is dumped.
+		if !opts.DataOnly && kind != "shadow" {
+			fmt.Fprintf(out, "%s;\n", o.sql)
+		}
+		if !opts.SchemaOnly && kind != "virtual" {
+			if kind == "shadow" {
+				fmt.Fprintf(out, "DELETE FROM %s;\n", quoteIdentifier(o.name))
+			}
+			if err := d.dumpRows(out, o.name); err != nil {
+				return err
+			}
+		}
+	}
+
+	if hasSequence && len(opts.Tables) == 0 && !opts.SchemaOnly {
+		fmt.Fprintln(out, "DELETE FROM sqlite_sequence;")
+		if err := d.dumpRows(out, "sqlite_sequence"); err != nil {
+			return err
+		}
+	}
+
+	if !opts.DataOnly {
+		for _, o := range objects {
+			switch {
+			case o.kind == "table":
+				continue
+			case o.kind == "view" && len(opts.Tables) > 0:
+				continue
+			case o.kind != "view" && !selected(o.table):
+				continue
+			}
+			fmt.Fprintf(out, "%s;\n", o.sql)
+		}
+	}
+
+	fmt.Fprintln(out, "COMMIT;")
+	return out.Flush()
+}
+
+// dumpRows writes an INSERT statement for every row of table. Generated
+// columns are left out and recomputed on insert.
+func (d *Connection) dumpRows(w io.Writer, table string) error {
+	rows, err := d.Query("SELECT name, hidden FROM pragma_table_xinfo(?, 'main') ORDER BY cid", table)
+	if err != nil {
+		return err
+	}
+	var names, values []string
+	generated := false
+	for rows.Next() {
+		var name string
+		var hidden int
+		if err := rows.Scan(&name, &hidden); err != nil {
+			rows.Close()
+			return err
+		}
+		if hidden != 0 {
+			generated = true
+			continue
+		}
+		names = append(names, quoteIdentifier(name))
+		values = append(values, "quote("+quoteIdentifier(name)+")")
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	target := quoteIdentifier(table)
+	if table == "sqlite_sequence" {
+		target = table
+	}
+	if generated {
+		target += "(" + strings.Join(names, ",") + ")"
+	}
+
+	rows, err = d.Query(fmt.Sprintf("SELECT %s FROM main.%s", strings.Join(values, " || ',' || "), quoteIdentifier(table)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		err := rows.ColumnTextView(0, func(text []byte) error {
+			_, err := fmt.Fprintf(w, "INSERT INTO %s VALUES(%s);\n", target, text)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// Restore reads an SQL script, such as the output of Dump, and executes it
+// one complete statement at a time. If a statement fails while a transaction
+// opened by the script is active, the transaction is rolled back.
+func (d *Connection) Restore(r io.Reader) error {
+	reader := bufio.NewReader(r)
+	var statement strings.Builder
+	line, start := 0, 1
+	for {
+		text, err := reader.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+		if text != "" {
+			line++
+			if strings.TrimSpace(statement.String()) == "" {
+				statement.Reset()
+				start = line
+			}
+			statement.WriteString(text)
+		}
+
+		if sql := statement.String(); (err == io.EOF && strings.TrimSpace(sql) != "") || isCompleteStatement(sql) {
+			if execErr := d.ExecScript(sql); execErr != nil {
+				if C.sqlite3_get_autocommit(d.h.ptr) == 0 {
+					execErr = errors.Join(execErr, d.Exec("ROLLBACK"))
+				}
+				return fmt.Errorf("restore: statement at line %d: %w", start, execErr)
+			}
+			statement.Reset()
+		}
+		if err == io.EOF {
+			return nil
+		}
+	}
+}
+
+func isCompleteStatement(sql string) bool {
+	s := newDatabaseString(sql)
+	defer s.Close()
+	return C.sqlite3_complete(s.h.ptr) != 0
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+const dumpSchema = `CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, avatar BLOB, score REAL);
+CREATE TABLE items (id INTEGER PRIMARY KEY, price INTEGER, total INTEGER GENERATED ALWAYS AS (price * 2));
+CREATE INDEX users_name ON users (name);
+CREATE VIEW names AS SELECT name FROM users;
+CREATE TRIGGER users_delete AFTER DELETE ON users BEGIN DELETE FROM items; END;
+INSERT INTO users (name, avatar, score) VALUES ('it''s', X'00FF10', 0.1), (NULL, NULL, 1e100);
+DELETE FROM users WHERE id = 2;
+INSERT INTO items (price) VALUES (21);`
+
+func TestDump(t *testing.T) {
+	db := openWithSchema(t, dumpSchema)
+	defer db.Close()
+
+	var out bytes.Buffer
+	assert.NoError(t, db.Dump(&out, goliat.DumpOptions{}))
+	dump := out.String()
+
+	assert.True(t, strings.HasPrefix(dump, "PRAGMA foreign_keys=OFF;\nBEGIN TRANSACTION;\n"))
+	assert.Contains(t, dump, `INSERT INTO "users" VALUES(1,'it''s',X'00FF10',0.1);`)
+	assert.Contains(t, dump, `INSERT INTO "items"("id","price") VALUES(1,21);`)
+	assert.Contains(t, dump, "DELETE FROM sqlite_sequence;\nINSERT INTO sqlite_sequence VALUES('users',2);\n")
+	assert.Contains(t, dump, "CREATE TRIGGER users_delete")
+	assert.True(t, strings.HasSuffix(dump, "COMMIT;\n"))
+
+	restored, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer restored.Close()
+	assert.NoError(t, restored.Restore(strings.NewReader(dump)))
+
+	var again bytes.Buffer
+	assert.NoError(t, restored.Dump(&again, goliat.DumpOptions{}))
+	assert.Equal(t, dump, again.String())
+
+	var total int
+	assert.NoError(t, restored.QueryRow("SELECT total FROM items").Scan(&total))
+	assert.Equal(t, 42, total)
+	assert.NoError(t, restored.Exec("INSERT INTO users (name) VALUES ('next')"))
+	assert.Equal(t, int64(3), restored.LastInsertRowId())
+}
+
+func TestDumpOptions(t *testing.T) {
+	db := openWithSchema(t, dumpSchema)
+	defer db.Close()
+
+	var out bytes.Buffer
+	assert.NoError(t, db.Dump(&out, goliat.DumpOptions{Tables: []string{"items"}, SchemaOnly: true}))
+	dump := out.String()
+	assert.Contains(t, dump, "CREATE TABLE items")
+	assert.NotContains(t, dump, "users")
+	assert.NotContains(t, dump, "INSERT")
+
+	out.Reset()
+	assert.NoError(t, db.Dump(&out, goliat.DumpOptions{DataOnly: true}))
+	assert.NotContains(t, out.String(), "CREATE")
+	assert.Contains(t, out.String(), "INSERT INTO \"users\"")
+
+	assert.Error(t, db.Dump(&out, goliat.DumpOptions{DataOnly: true, SchemaOnly: true}))
+}
+
+func TestRestoreRollsBackOnError(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Restore(strings.NewReader("CREATE TABLE foo (bar);\nBEGIN;\nINSERT INTO foo VALUES ('a;\nb');\nINSERT INTO missing VALUES (1);\nCOMMIT;\n"))
+	assert.ErrorContains(t, err, "line 5")
+
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 0, count)
+	assert.NoError(t, db.Exec("BEGIN"))
+	assert.NoError(t, db.Exec("ROLLBACK"))
+}
@@ -0,0 +1,458 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"errors"
+	"io"
+	"math"
+	"sync"
+)
+
+// ErrInjectedFault is returned by the files of a FaultVFS when an injected
+// fault fails an operation, and by all operations after a simulated power
+// loss. SQLite reports it as the IOERR code matching the operation.
+var ErrInjectedFault = errors.New("injected fault")
+
+// FaultOp is the file operation a Fault applies to.
+type FaultOp int
+
+const (
+	FaultOpRead FaultOp = iota
+	FaultOpWrite
+	FaultOpTruncate
+	FaultOpSync
+)
+
+// FaultKind is what happens when a Fault triggers.
+type FaultKind int
+
+const (
+	// FaultKindIOErr fails the operation with ErrInjectedFault.
+	FaultKindIOErr FaultKind = iota
+	// FaultKindFull fails a write with FULL, as if the disk were full.
+	FaultKindFull
+	// FaultKindShortWrite writes only the first half of the data, which
+	// SQLite reports as FULL.
+	FaultKindShortWrite
+	// FaultKindTornWrite loses power in the middle of a write: unsynced
+	// writes are discarded but the first half of the write reaches the
+	// storage.
+	FaultKindTornWrite
+	// FaultKindPowerLoss loses power before the operation: unsynced writes
+	// are discarded.
+	FaultKindPowerLoss
+)
+
+// Fault describes a failure injected by a FaultVFS.
+type Fault struct {
+	Op   FaultOp
+	Kind FaultKind
+	// After is the number of matching operations that succeed before the
+	// fault triggers.
+	After int
+	// FileType restricts the fault to the files opened with the given type,
+	// such as OpenFlagMainDB or OpenFlagMainJournal. Zero matches all
+	// files.
+	FileType OpenFlag
+	// Persistent keeps failing all matching operations once the fault
+	// triggered, instead of only the first one.
+	Persistent bool
+}
+
+const faultFileTypes = OpenFlagMainDB | OpenFlagTempDB | OpenFlagTransientDB | OpenFlagMainJournal |
+	OpenFlagTempJournal | OpenFlagSubJournal | OpenFlagSuperJournal | OpenFlagWAL
+
+type faultEntry struct {
+	Fault
+	seen      int
+	triggered bool
+}
+
+// faultUndo restores data at offset off of a file to its content at the last
+// sync.
+type faultUndo struct {
+	off  int64
+	data []byte
+}
+
+// faultFileState tracks the writes made to a file since it was last synced.
+type faultFileState struct {
+	flags OpenFlag
+	// syncedSize is the size of the file at the last sync, -1 if it was
+	// created and never synced.
+	syncedSize int64
+	undo       []faultUndo
+	open       map[*faultFile]struct{}
+}
+
+// FaultVFS wraps a VFS and injects failures into the operations on its files,
+// to test how an application copes with I/O errors and crashes.
+//
+// A simulated power loss, triggered by a fault or by calling PowerLoss,
+// discards all the writes that were not synced and makes every later
+// operation fail until Reset is called. The connections that were open
+// should then be closed and the database reopened, which lets SQLite
+// recover from its journal as it would after a real crash.
+//
+// Discarding writes requires the base VFS to keep files across connections,
+// for example one returned by NewMemoryVFS.
+type FaultVFS struct {
+	base VFS
+
+	mu        sync.Mutex
+	faults    []*faultEntry
+	files     map[string]*faultFileState
+	crashed   bool
+	triggered int
+}
+
+// NewFaultVFS returns a FaultVFS over base, initially injecting no faults.
+// It must be registered with RegisterVFS to be used by connections.
+func NewFaultVFS(base VFS) *FaultVFS {
+	return &FaultVFS{base: base, files: make(map[string]*faultFileState)}
+}
+
+// Inject adds a fault. Several faults can be active at the same time.
+func (v *FaultVFS) Inject(fault Fault) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.faults = append(v.faults, &faultEntry{Fault: fault})
+}
+
+// Triggered returns how many operations were affected by faults since the
+// last Reset.
+func (v *FaultVFS) Triggered() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.triggered
+}
+
+// Reset removes all the faults and ends a simulated power loss. Unsynced
+// writes made before it are kept.
+func (v *FaultVFS) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.faults = nil
+	v.crashed = false
+	v.triggered = 0
+}
+
+// PowerLoss simulates a power loss now.
+func (v *FaultVFS) PowerLoss() error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.powerLoss()
+}
+
+func (v *FaultVFS) powerLoss() error {
+	v.crashed = true
+	var errs []error
+	for name, state := range v.files {
+		errs = append(errs, v.restore(name, state))
+	}
+	return errors.Join(errs...)
+}
+
+// restore brings the file name back to its content at the last sync.
+func (v *FaultVFS) restore(name string, state *faultFileState) error {
+	if state.syncedSize < 0 {
+		delete(v.files, name)
+		for f := range state.open {
+			f.state = nil
+		}
+		return v.base.Delete(name, false)
+	}
+	if len(state.undo) == 0 {
+		return nil
+	}
+
+	var file File
+	for f := range state.open {
+		file = f.file
+		break
+	}
+	if file == nil {
+		flags := state.flags &^ (OpenFlagCreate | OpenFlagExclusive | OpenFlagDeleteOnClose)
+		f, _, err := v.base.Open(name, flags)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		file = f
+	}
+	for i := len(state.undo) - 1; i >= 0; i-- {
+		if _, err := file.WriteAt(state.undo[i].data, state.undo[i].off); err != nil {
+			return err
+		}
+	}
+	state.undo = nil
+	return file.Truncate(state.syncedSize)
+}
+
+// check reports the fault triggered by an operation on f, if any.
+func (v *FaultVFS) check(f *faultFile, op FaultOp) *faultEntry {
+	for i, fault := range v.faults {
+		if fault.Op != op || (fault.FileType != 0 && f.flags&faultFileTypes != fault.FileType) {
+			continue
+		}
+		if fault.triggered {
+			v.triggered++
+			return fault
+		}
+		fault.seen++
+		if fault.seen <= fault.After {
+			continue
+		}
+		v.triggered++
+		if fault.Persistent {
+			fault.triggered = true
+		} else {
+			v.faults = append(v.faults[:i], v.faults[i+1:]...)
+		}
+		return fault
+	}
+	return nil
+}
+
+// inject applies the fault triggered by a non-write operation on f, if any.
+func (v *FaultVFS) inject(f *faultFile, op FaultOp) error {
+	if v.crashed {
+		return ErrInjectedFault
+	}
+	fault := v.check(f, op)
+	if fault == nil {
+		return nil
+	}
+	if fault.Kind == FaultKindPowerLoss || fault.Kind == FaultKindTornWrite {
+		return errors.Join(ErrInjectedFault, v.powerLoss())
+	}
+	return ErrInjectedFault
+}
+
+func (v *FaultVFS) Open(name string, flags OpenFlag) (File, OpenFlag, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.crashed {
+		return nil, 0, ErrInjectedFault
+	}
+	existed := false
+	if name != "" {
+		var err error
+		if existed, err = v.base.Access(name, AccessFlagExists); err != nil {
+			return nil, 0, err
+		}
+	}
+	file, resultFlags, err := v.base.Open(name, flags)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	f := &faultFile{vfs: v, file: file, name: name, flags: flags}
+	if name != "" && flags&OpenFlagDeleteOnClose == 0 {
+		state, ok := v.files[name]
+		if !ok {
+			state = &faultFileState{flags: flags, syncedSize: -1, open: make(map[*faultFile]struct{})}
+			if existed {
+				size, err := file.FileSize()
+				if err != nil {
+					file.Close()
+					return nil, 0, err
+				}
+				state.syncedSize = size
+			}
+			v.files[name] = state
+		}
+		state.open[f] = struct{}{}
+		f.state = state
+	}
+	return f, resultFlags, nil
+}
+
+func (v *FaultVFS) Delete(name string, syncDir bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.crashed {
+		return ErrInjectedFault
+	}
+	if state, ok := v.files[name]; ok {
+		delete(v.files, name)
+		for f := range state.open {
+			f.state = nil
+		}
+	}
+	return v.base.Delete(name, syncDir)
+}
+
+func (v *FaultVFS) Access(name string, flags AccessFlag) (bool, error) {
+	return v.base.Access(name, flags)
+}
+
+func (v *FaultVFS) FullPathname(name string) (string, error) {
+	return v.base.FullPathname(name)
+}
+
+type faultFile struct {
+	vfs   *FaultVFS
+	file  File
+	name  string
+	flags OpenFlag
+	// state is nil for temporary files and for files deleted while open,
+	// which do not survive a power loss anyway.
+	state *faultFileState
+}
+
+func (f *faultFile) Close() error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if f.state != nil {
+		delete(f.state.open, f)
+	}
+	return f.file.Close()
+}
+
+func (f *faultFile) ReadAt(p []byte, off int64) (int, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if err := f.vfs.inject(f, FaultOpRead); err != nil {
+		return 0, err
+	}
+	return f.file.ReadAt(p, off)
+}
+
+// saveUndo records the content of the file in [off, end) so that it can be
+// restored by a power loss.
+func (f *faultFile) saveUndo(off, end int64) error {
+	if f.state == nil || f.state.syncedSize < 0 {
+		return nil
+	}
+	end = min(end, f.state.syncedSize)
+	size, err := f.file.FileSize()
+	if err != nil {
+		return err
+	}
+	end = min(end, size)
+	if off >= end {
+		return nil
+	}
+	data := make([]byte, end-off)
+	if _, err := f.file.ReadAt(data, off); err != nil && err != io.EOF {
+		return err
+	}
+	f.state.undo = append(f.state.undo, faultUndo{off: off, data: data})
+	return nil
+}
+
+func (f *faultFile) WriteAt(p []byte, off int64) (int, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if f.vfs.crashed {
+		return 0, ErrInjectedFault
+	}
+	data := p
+	if fault := f.vfs.check(f, FaultOpWrite); fault != nil {
+		switch fault.Kind {
+		case FaultKindIOErr:
+			return 0, ErrInjectedFault
+		case FaultKindFull:
+			return 0, newDatabaseError(FULL, "database or disk is full")
+		case FaultKindShortWrite:
+			data = p[:len(p)/2]
+		case FaultKindPowerLoss:
+			return 0, errors.Join(ErrInjectedFault, f.vfs.powerLoss())
+		case FaultKindTornWrite:
+			if err := f.vfs.powerLoss(); err != nil {
+				return 0, err
+			}
+			// The torn part reached the storage before the power loss, so
+			// it is not undone by a later one.
+			if f.state != nil {
+				_, err := f.file.WriteAt(p[:len(p)/2], off)
+				return 0, errors.Join(ErrInjectedFault, err)
+			}
+			return 0, ErrInjectedFault
+		}
+	}
+	if err := f.saveUndo(off, off+int64(len(data))); err != nil {
+		return 0, err
+	}
+	return f.file.WriteAt(data, off)
+}
+
+func (f *faultFile) Truncate(size int64) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if err := f.vfs.inject(f, FaultOpTruncate); err != nil {
+		return err
+	}
+	if err := f.saveUndo(size, math.MaxInt64); err != nil {
+		return err
+	}
+	return f.file.Truncate(size)
+}
+
+func (f *faultFile) Sync(flags SyncFlag) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if err := f.vfs.inject(f, FaultOpSync); err != nil {
+		return err
+	}
+	if err := f.file.Sync(flags); err != nil {
+		return err
+	}
+	if f.state != nil {
+		size, err := f.file.FileSize()
+		if err != nil {
+			return err
+		}
+		f.state.syncedSize = size
+		f.state.undo = nil
+	}
+	return nil
+}
+
+func (f *faultFile) FileSize() (int64, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if f.vfs.crashed {
+		return 0, ErrInjectedFault
+	}
+	return f.file.FileSize()
+}
+
+func (f *faultFile) Lock(level LockLevel) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if f.vfs.crashed {
+		return ErrInjectedFault
+	}
+	return f.file.Lock(level)
+}
+
+func (f *faultFile) Unlock(level LockLevel) error {
+	return f.file.Unlock(level)
+}
+
+func (f *faultFile) CheckReservedLock() (bool, error) {
+	return f.file.CheckReservedLock()
+}
+
+func (f *faultFile) SectorSize() int {
+	return f.file.SectorSize()
+}
+
+func (f *faultFile) DeviceCharacteristics() DeviceCharacteristic {
+	return f.file.DeviceCharacteristics()
+}
@@ -0,0 +1,145 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func openFaultVFS(t *testing.T, name string) *goliat.FaultVFS {
+	vfs := goliat.NewFaultVFS(goliat.NewMemoryVFS())
+	assert.NoError(t, goliat.RegisterVFS(name, vfs, false))
+	t.Cleanup(func() {
+		assert.NoError(t, goliat.UnregisterVFS(name))
+	})
+	return vfs
+}
+
+func assertErrorCode(t *testing.T, expected goliat.ErrorCode, err error) {
+	var dbErr *goliat.DatabaseError
+	if assert.True(t, errors.As(err, &dbErr)) {
+		assert.Equal(t, expected, dbErr.Code&0xff)
+	}
+}
+
+func TestFaultVFSErrors(t *testing.T) {
+	vfs := openFaultVFS(t, "fault-errors")
+	db, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "fault-errors"})
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE foo (bar TEXT)"))
+
+	vfs.Inject(goliat.Fault{Op: goliat.FaultOpWrite, Kind: goliat.FaultKindIOErr, FileType: goliat.OpenFlagMainDB})
+	assertErrorCode(t, goliat.IOERR, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	assert.Equal(t, 1, vfs.Triggered())
+
+	vfs.Inject(goliat.Fault{Op: goliat.FaultOpWrite, Kind: goliat.FaultKindFull, After: 1})
+	assertErrorCode(t, goliat.FULL, db.Exec("INSERT INTO foo VALUES ('baz')"))
+
+	vfs.Inject(goliat.Fault{Op: goliat.FaultOpWrite, Kind: goliat.FaultKindShortWrite})
+	assertErrorCode(t, goliat.FULL, db.Exec("INSERT INTO foo VALUES ('baz')"))
+
+	vfs.Inject(goliat.Fault{Op: goliat.FaultOpSync, Kind: goliat.FaultKindIOErr, Persistent: true})
+	assertErrorCode(t, goliat.IOERR, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	assertErrorCode(t, goliat.IOERR, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	assert.Equal(t, 5, vfs.Triggered())
+
+	vfs.Reset()
+	assert.Equal(t, 0, vfs.Triggered())
+	assert.NoError(t, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 1, count)
+}
+
+func TestFaultVFSCrashes(t *testing.T) {
+	vfs := openFaultVFS(t, "fault-crashes")
+	open := func() *goliat.Connection {
+		db, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "fault-crashes"})
+		assert.NoError(t, err)
+		return db
+	}
+
+	db := open()
+	assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT)"))
+	assert.NoError(t, db.Exec("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100) INSERT INTO foo SELECT i, printf('%0500d', i) FROM n"))
+	assert.NoError(t, db.Close())
+
+	// Crash at every write, sync and truncate of a transaction updating all
+	// the rows in turn, and check that it is either fully applied or not at
+	// all after recovery.
+	expected := 100
+	for _, kind := range []goliat.FaultKind{goliat.FaultKindPowerLoss, goliat.FaultKindTornWrite} {
+		for _, op := range []goliat.FaultOp{goliat.FaultOpWrite, goliat.FaultOpSync, goliat.FaultOpTruncate} {
+			for after := 0; ; after++ {
+				vfs.Inject(goliat.Fault{Op: op, Kind: kind, After: after})
+				db := open()
+				tx, err := db.BeginTransaction()
+				assert.NoError(t, err)
+				err = db.Exec("UPDATE foo SET bar = printf('%0500d', id + 1)")
+				if err == nil {
+					err = db.Exec("DELETE FROM foo WHERE id = (SELECT MAX(id) FROM foo)")
+				}
+				if err == nil {
+					err = tx.Commit()
+				} else {
+					tx.Rollback()
+				}
+				db.Close()
+				triggered := vfs.Triggered()
+				vfs.Reset()
+				if err == nil {
+					expected--
+				}
+
+				db = open()
+				var integrity string
+				assert.NoError(t, db.QueryRow("PRAGMA integrity_check").Scan(&integrity))
+				assert.Equal(t, "ok", integrity)
+				var count int
+				assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+				assert.NoError(t, db.Close())
+				// A crash after the commit was durable keeps it.
+				if count == expected-1 {
+					expected--
+				}
+				assert.Equal(t, expected, count)
+				if triggered == 0 {
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestFaultVFSPowerLoss(t *testing.T) {
+	vfs := openFaultVFS(t, "fault-power-loss")
+	db, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "fault-power-loss"})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("PRAGMA synchronous = OFF"))
+	assert.NoError(t, db.Exec("CREATE TABLE foo (bar TEXT)"))
+	assert.NoError(t, vfs.PowerLoss())
+	assert.Error(t, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	db.Close()
+	vfs.Reset()
+
+	// Nothing was ever synced, so the database is gone.
+	_, err = goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "fault-power-loss", MustExist: true})
+	assert.Error(t, err)
+}
@@ -19,6 +19,7 @@ package goliat
 #cgo LDFLAGS: -lm
 #include "sqlite3.h"
 #include <stdlib.h>
+#include <stdint.h>
 const sqlite3_destructor_type transient = (sqlite3_destructor_type)-1;
 const sqlite3_destructor_type staticv   = (sqlite3_destructor_type)0;
 const int sqlite3_datatype_integer = SQLITE_INTEGER;
@@ -26,6 +27,9 @@ const int sqlite3_datatype_text    = SQLITE_TEXT;
 const int sqlite3_datatype_blob    = SQLITE_BLOB;
 const int sqlite3_datatype_float    = SQLITE_FLOAT;
 const int sqlite3_datatype_null    = SQLITE_NULL;
+const unsigned char sqlite3_encoding_utf8    = SQLITE_UTF8;
+const unsigned char sqlite3_encoding_utf16   = SQLITE_UTF16;
+const char empty_text[1] = {0};
 */
 import "C"
 
@@ -34,6 +38,10 @@ import (
 	"fmt"
 	"io"
 	"runtime"
+	"runtime/cgo"
+	"slices"
+	"strings"
+	"sync"
 	"unsafe"
 )
 
@@ -78,6 +86,12 @@ type ZeroBlob struct {
 	Size uint64
 }
 
+// Text16 is a UTF-16 string in native byte order. Binding a Text16 stores the
+// value through sqlite3_bind_text64 with SQLITE_UTF16 and scanning into
+// a *Text16 reads it with sqlite3_column_text16, which avoids a round trip
+// through UTF-8 when working with UTF-16 encoded databases.
+type Text16 []uint16
+
 type DatabaseError struct {
 	Code    ErrorCode
 	Message string
@@ -123,21 +137,61 @@ func newDatabaseString(s string) *databaseString {
 }
 
 type connectionHandle struct {
-	ptr *C.sqlite3
+	// mu guards ptr against Close for the methods that may be called from
+	// another goroutine, such as Interrupt.
+	mu        sync.Mutex
+	ptr       *C.sqlite3
+	callbacks map[string]cgo.Handle
 }
 
 func (h *connectionHandle) Close() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
 	if h.ptr == nil {
 		return nil
 	}
+	// Hooks whose handles are released below must not outlive them.
+	C.sqlite3_progress_handler(h.ptr, 0, nil, nil)
+	C.sqlite3_set_authorizer(h.ptr, nil, nil)
+	C.sqlite3_wal_hook(h.ptr, nil, nil)
+	// The callback handles are released below, but with statements still
+	// open sqlite3_close_v2 leaves a zombie connection that keeps tracing
+	// until they are finalized. Disable tracing first, which loses the
+	// TraceMaskClose event in that case.
+	if C.sqlite3_next_stmt(h.ptr, nil) != nil {
+		C.sqlite3_trace_v2(h.ptr, 0, nil, nil)
+	}
 	ec := C.sqlite3_close_v2(h.ptr)
 	if ec != C.SQLITE_OK {
 		return newDatabaseError(ErrorCode(ec), "failed to close database")
 	}
 	h.ptr = nil
+	for name := range h.callbacks {
+		h.replaceCallback(name, nil)
+	}
 	return nil
 }
 
+// replaceCallback keeps value alive as the Go side of the SQLite callback
+// identified by name and returns the handle to pass to SQLite as user data.
+// The previously registered value is released. A nil value only releases the
+// previous one and returns a zero handle.
+func (h *connectionHandle) replaceCallback(name string, value any) C.uintptr_t {
+	if old, ok := h.callbacks[name]; ok {
+		old.Delete()
+		delete(h.callbacks, name)
+	}
+	if value == nil {
+		return 0
+	}
+	if h.callbacks == nil {
+		h.callbacks = make(map[string]cgo.Handle)
+	}
+	handle := cgo.NewHandle(value)
+	h.callbacks[name] = handle
+	return C.uintptr_t(handle)
+}
+
 type statementHandle struct {
 	ptr *C.sqlite3_stmt
 }
@@ -196,16 +250,56 @@ func newDatabaseConnection(handle *connectionHandle) *Connection {
 	return result
 }
 
-// Open opens a SQLite database file (creates it if it doesn’t exist)
+// Open opens or creates the database file filename. The name can also be a
+// "file:" URI, whose query parameters such as mode=ro are honoured, or
+// ":memory:" for a private in-memory database.
 func Open(filename string) (*Connection, error) {
+	return OpenWithOptions(filename, OpenOptions{})
+}
+
+type OpenOptions struct {
+	// VFS is the name of the VFS used to access the database, the default
+	// one when empty. See RegisterVFS.
+	VFS string
+	// ReadOnly opens the database in read-only mode.
+	ReadOnly bool
+	// MustExist fails instead of creating the database when it does not
+	// exist.
+	MustExist bool
+}
+
+// OpenWithOptions is like Open with the given options.
+func OpenWithOptions(filename string, opts OpenOptions) (*Connection, error) {
 	cfilename := newDatabaseString(filename)
 	defer cfilename.Close()
 
+	var vfsRaw *C.char
+	if opts.VFS != "" {
+		s := newDatabaseString(opts.VFS)
+		defer s.Close()
+		vfsRaw = s.h.ptr
+	}
+
+	flags := C.int(C.SQLITE_OPEN_URI)
+	switch {
+	case opts.ReadOnly:
+		flags |= C.SQLITE_OPEN_READONLY
+	case opts.MustExist:
+		flags |= C.SQLITE_OPEN_READWRITE
+	default:
+		flags |= C.SQLITE_OPEN_READWRITE | C.SQLITE_OPEN_CREATE
+	}
+
 	handle := connectionHandle{ptr: nil}
-	if ec := C.sqlite3_open(cfilename.h.ptr, &handle.ptr); ec != C.SQLITE_OK {
+	if ec := C.sqlite3_open_v2(cfilename.h.ptr, &handle.ptr, flags, vfsRaw); ec != C.SQLITE_OK {
+		message := "failed to open database"
+		if handle.ptr != nil {
+			message = C.GoString(C.sqlite3_errmsg(handle.ptr))
+			C.sqlite3_close_v2(handle.ptr)
+		}
 		return nil, &DatabaseError{
 			Code:    ErrorCode(ec),
-			Message: "failed to open database",
+			Message: message,
 		}
 	}
 
@@ -259,10 +353,29 @@ func (d *Connection) Exec(sql string, values ...any) error {
 	}
 }
 
+// ExecScript runs every statement in sql, which may contain several
+// statements separated by semicolons. Parameters are not supported.
+func (d *Connection) ExecScript(sql string) error {
+	sqlRaw := newDatabaseString(sql)
+	defer sqlRaw.Close()
+
+	var errmsg *C.char
+	ec := C.sqlite3_exec(d.h.ptr, sqlRaw.h.ptr, nil, nil, &errmsg)
+	if ec != C.SQLITE_OK {
+		message := C.GoString(errmsg)
+		C.sqlite3_free(unsafe.Pointer(errmsg))
+		return newDatabaseError(ErrorCode(ec), message)
+	}
+	return nil
+}
+
 type QueryIterator struct {
 	stmt *Statement
 	err  error
 	done bool
+	// pending is set when the current row was already stepped by
+	// ExecReturning and must be returned by the next call to Next.
+	pending bool
 }
 
 func (d *Connection) Query(sql string, args ...any) (*QueryIterator, error) {
@@ -287,10 +400,33 @@ func (d *Connection) Query(sql string, args ...any) (*QueryIterator, error) {
 	return result, nil
 }
 
+// ExecReturning runs a statement that returns rows, such as INSERT, UPDATE or
+// DELETE with a RETURNING clause, and returns an iterator over them. Unlike
+// Query, the statement is stepped once before returning, so its changes are
+// made even if the rows are never read. The iterator must be closed.
+func (d *Connection) ExecReturning(sql string, args ...any) (*QueryIterator, error) {
+	rows, err := d.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	if rows.Next() {
+		rows.pending = true
+	} else if rows.err != nil {
+		err := d.newDatabaseError()
+		rows.Close()
+		return nil, err
+	}
+	return rows, nil
+}
+
 func (r *QueryIterator) Next() bool {
 	if r.done || r.err != nil {
 		return false
 	}
+	if r.pending {
+		r.pending = false
+		return true
+	}
 
 	ec := r.stmt.Step()
 
@@ -317,6 +453,32 @@ func (r *QueryIterator) Scan(dest ...any) error {
 	return ErrNoRows
 }
 
+// Err returns the error, if any, that stopped the iteration.
+func (r *QueryIterator) Err() error {
+	return r.err
+}
+
+// ColumnBlobView calls fn with the bytes of column i of the current row
+// without copying them. See Statement.ColumnBlobView.
+func (r *QueryIterator) ColumnBlobView(i int, fn func([]byte) error) error {
+	return r.stmt.ColumnBlobView(i, fn)
+}
+
+// ColumnTextView calls fn with the text of column i of the current row
+// without copying it. See Statement.ColumnTextView.
+func (r *QueryIterator) ColumnTextView(i int, fn func([]byte) error) error {
+	return r.stmt.ColumnTextView(i, fn)
+}
+
+// Columns returns the names of the result columns.
+func (r *QueryIterator) Columns() []string {
+	columns := make([]string, r.stmt.ColumnCount())
+	for i := range columns {
+		columns[i] = r.stmt.ColumnName(i)
+	}
+	return columns
+}
+
 func (r *QueryIterator) Close() error {
 	return r.stmt.Close()
 }
@@ -371,6 +533,11 @@ func (s *Statement) ColumnCount() int {
 	return int(C.sqlite3_column_count(s.h.ptr))
 }
 
+// ColumnName returns the name of result column i.
+func (s *Statement) ColumnName(i int) string {
+	return C.GoString(C.sqlite3_column_name(s.h.ptr, C.int(i)))
+}
+
 type BindValue struct {
 	value any
 }
@@ -391,6 +558,10 @@ func (b *BindValue) SetFloat64(value float64) {
 	b.value = value
 }
 
+func (b *BindValue) SetText16(value Text16) {
+	b.value = value
+}
+
 func (b *BindValue) SetBlob(value []byte) {
 	b.value = value
 }
@@ -428,30 +599,49 @@ func boolToInt(b bool) int {
 	return 0
 }
 
+// bindText binds a Go string by pointer and explicit byte length, so embedded
+// NUL bytes are preserved and no intermediate C copy is allocated.
+func (stmt *Statement) bindText(index int, value string) C.int {
+	ptr := &C.empty_text[0]
+	if len(value) > 0 {
+		ptr = (*C.char)(unsafe.Pointer(unsafe.StringData(value)))
+	}
+	return C.sqlite3_bind_text64(stmt.h.ptr, C.int(index), ptr, C.sqlite3_uint64(len(value)), C.transient, C.sqlite3_encoding_utf8)
+}
+
+func (stmt *Statement) bindText16(index int, value Text16) C.int {
+	ptr := &C.empty_text[0]
+	if len(value) > 0 {
+		ptr = (*C.char)(unsafe.Pointer(&value[0]))
+	}
+	return C.sqlite3_bind_text64(stmt.h.ptr, C.int(index), ptr, C.sqlite3_uint64(len(value)*2), C.transient, C.sqlite3_encoding_utf16)
+}
+
 func (stmt *Statement) BindValue(index int, value any) error {
+	var ec C.int
 	switch v := value.(type) {
 	case bool:
-		C.sqlite3_bind_int(stmt.h.ptr, C.int(index), C.int(boolToInt(v)))
+		ec = C.sqlite3_bind_int(stmt.h.ptr, C.int(index), C.int(boolToInt(v)))
 	case int:
-		C.sqlite3_bind_int(stmt.h.ptr, C.int(index), C.int(v))
+		ec = C.sqlite3_bind_int(stmt.h.ptr, C.int(index), C.int(v))
 	case int64:
-		C.sqlite3_bind_int64(stmt.h.ptr, C.int(index), C.sqlite3_int64(int64(v)))
+		ec = C.sqlite3_bind_int64(stmt.h.ptr, C.int(index), C.sqlite3_int64(int64(v)))
 	case float64:
-		C.sqlite3_bind_double(stmt.h.ptr, C.int(index), C.double(v))
+		ec = C.sqlite3_bind_double(stmt.h.ptr, C.int(index), C.double(v))
 	case []byte:
 		if len(v) == 0 {
-			C.sqlite3_bind_blob(stmt.h.ptr, C.int(index), nil, 0, C.transient)
+			ec = C.sqlite3_bind_blob(stmt.h.ptr, C.int(index), nil, 0, C.transient)
 		} else {
-			C.sqlite3_bind_blob(stmt.h.ptr, C.int(index), unsafe.Pointer(&v[0]), C.int(len(v)), C.transient)
+			ec = C.sqlite3_bind_blob(stmt.h.ptr, C.int(index), unsafe.Pointer(&v[0]), C.int(len(v)), C.transient)
 		}
 	case string:
-		cvalue := newDatabaseString(v)
-		defer cvalue.Close()
-		C.sqlite3_bind_text(stmt.h.ptr, C.int(index), cvalue.h.ptr, -1, C.transient)
+		ec = stmt.bindText(index, v)
+	case Text16:
+		ec = stmt.bindText16(index, v)
 	case nil:
-		C.sqlite3_bind_null(stmt.h.ptr, C.int(index))
+		ec = C.sqlite3_bind_null(stmt.h.ptr, C.int(index))
 	case ZeroBlob:
-		C.sqlite3_bind_zeroblob64(stmt.h.ptr, C.int(index), C.sqlite3_uint64(v.Size))
+		ec = C.sqlite3_bind_zeroblob64(stmt.h.ptr, C.int(index), C.sqlite3_uint64(v.Size))
 	default:
 		if handler, ok := value.(BindHandler); ok {
 			return stmt.BindValue(index, handler.ToSQLiteValue().value)
@@ -459,6 +649,9 @@ func (stmt *Statement) BindValue(index int, value any) error {
 		return fmt.Errorf("unknown type %T", value)
 	}
 
+	if ec != C.SQLITE_OK {
+		return stmt.db.newDatabaseError()
+	}
 	return nil
 }
 
@@ -509,12 +702,17 @@ func (c ColumnValue) Text() (string, error) {
 	if !c.IsText() {
 		return "", fmt.Errorf("not a text")
 	}
-	textPtr := C.sqlite3_column_text(c.stmt.h.ptr, C.int(c.index))
-	if textPtr != nil {
-		return C.GoString((*C.char)(unsafe.Pointer(textPtr))), nil
-	} else {
-		return "", nil
+	return c.stmt.columnText(c.index), nil
+}
+
+func (c ColumnValue) Text16() (Text16, error) {
+	if c.IsNull() {
+		return nil, nil
+	}
+	if !c.IsText() {
+		return nil, fmt.Errorf("not a text")
 	}
+	return c.stmt.columnText16(c.index), nil
 }
 
 func (c ColumnValue) Blob() ([]byte, error) {
@@ -545,10 +743,53 @@ func (stmt *Statement) Column(values ...any) error {
 	return nil
 }
 
+// ColumnBlobView calls fn with the bytes of column i without copying them.
+// The slice points into SQLite owned memory and is only valid until fn
+// returns; it must not be retained or modified.
+func (stmt *Statement) ColumnBlobView(i int, fn func([]byte) error) error {
+	data := C.sqlite3_column_blob(stmt.h.ptr, C.int(i))
+	dataLen := C.sqlite3_column_bytes(stmt.h.ptr, C.int(i))
+	if data == nil || dataLen == 0 {
+		return fn(nil)
+	}
+	return fn(unsafe.Slice((*byte)(data), int(dataLen)))
+}
+
+// ColumnTextView calls fn with the UTF-8 text of column i without copying it.
+// The same lifetime rules as ColumnBlobView apply.
+func (stmt *Statement) ColumnTextView(i int, fn func([]byte) error) error {
+	textPtr := C.sqlite3_column_text(stmt.h.ptr, C.int(i))
+	textLen := C.sqlite3_column_bytes(stmt.h.ptr, C.int(i))
+	if textPtr == nil || textLen == 0 {
+		return fn(nil)
+	}
+	return fn(unsafe.Slice((*byte)(unsafe.Pointer(textPtr)), int(textLen)))
+}
+
 func (stmt *Statement) columnDatatype(i int) int {
 	return int(C.sqlite3_column_type(stmt.h.ptr, C.int(i)))
 }
 
+// columnText reads a text column using its byte length rather than the NUL
+// terminator, so values with embedded NUL bytes are returned whole.
+func (stmt *Statement) columnText(i int) string {
+	textPtr := C.sqlite3_column_text(stmt.h.ptr, C.int(i))
+	if textPtr == nil {
+		return ""
+	}
+	textLen := C.sqlite3_column_bytes(stmt.h.ptr, C.int(i))
+	return C.GoStringN((*C.char)(unsafe.Pointer(textPtr)), textLen)
+}
+
+func (stmt *Statement) columnText16(i int) Text16 {
+	textPtr := C.sqlite3_column_text16(stmt.h.ptr, C.int(i))
+	if textPtr == nil {
+		return nil
+	}
+	textLen := int(C.sqlite3_column_bytes16(stmt.h.ptr, C.int(i))) / 2
+	return Text16(slices.Clone(unsafe.Slice((*uint16)(textPtr), textLen)))
+}
+
 func (stmt *Statement) columnValue(i int, value any) error {
 	switch v := value.(type) {
 	case *bool:
@@ -559,12 +800,9 @@ func (stmt *Statement) columnValue(i int, value any) error {
 	case *int64:
 		*v = int64(C.sqlite3_column_int64(stmt.h.ptr, C.int(i)))
 	case *string:
-		textPtr := C.sqlite3_column_text(stmt.h.ptr, C.int(i))
-		if textPtr != nil {
-			*v = C.GoString((*C.char)(unsafe.Pointer(textPtr)))
-		} else {
-			*v = ""
-		}
+		*v = stmt.columnText(i)
+	case *Text16:
+		*v = stmt.columnText16(i)
 	case *[]byte:
 		data := C.sqlite3_column_blob(stmt.h.ptr, C.int(i))
 		dataLen := C.sqlite3_column_bytes(stmt.h.ptr, C.int(i))
@@ -584,6 +822,11 @@ func (stmt *Statement) columnValue(i int, value any) error {
 				stmt:     stmt,
 				index:    i,
 			})
+		} else if writer, ok := value.(io.Writer); ok {
+			return stmt.ColumnBlobView(i, func(data []byte) error {
+				_, err := writer.Write(data)
+				return err
+			})
 		} else {
 			return fmt.Errorf("unsupported type %T", value)
 		}
@@ -596,12 +839,13 @@ type blobHandle struct {
 	ptr *C.sqlite3_blob
 }
 
-func (b *blobHandle) Close() {
+func (b *blobHandle) Close() C.int {
 	if b.ptr == nil {
-		return
+		return C.SQLITE_OK
 	}
-	C.sqlite3_blob_close(b.ptr)
+	ec := C.sqlite3_blob_close(b.ptr)
 	b.ptr = nil
+	return ec
 }
 
 type Blob struct {
@@ -617,8 +861,13 @@ func newDatabaseBlob(db *Connection, ptr *C.sqlite3_blob) *Blob {
 	return result
 }
 
-func (b *Blob) Close() {
-	b.h.Close()
+// Close releases the blob handle. It reports the error of a write that
+// could not be committed, if any.
+func (b *Blob) Close() error {
+	if ec := b.h.Close(); ec != C.SQLITE_OK {
+		return b.db.newDatabaseError()
+	}
+	return nil
 }
 
 type DatabaseName string
@@ -649,6 +898,16 @@ func (d *Connection) BlobOpen(databaseName DatabaseName, tableName string, colum
 	return blob, nil
 }
 
+// Reopen moves the blob handle to another row of the same table and column,
+// avoiding the table and column lookup done by BlobOpen.
+func (b *Blob) Reopen(rowId int64) error {
+	ec := C.sqlite3_blob_reopen(b.h.ptr, C.sqlite3_int64(rowId))
+	if ec != C.SQLITE_OK {
+		return b.db.newDatabaseError()
+	}
+	return nil
+}
+
 func (b *Blob) Bytes() int {
 	return int(C.sqlite3_blob_bytes(b.h.ptr))
 }
@@ -764,6 +1023,18 @@ func (r *BlobStream) Read(p []byte) (int, error) {
 	return toRead, nil
 }
 
+// Reopen moves the stream to another row and rewinds it to the start.
+func (r *BlobStream) Reopen(rowId int64) error {
+	if r.blob == nil || r.blob.h == nil || r.blob.h.ptr == nil {
+		return newDatabaseError(ErrorCode(C.SQLITE_MISUSE), "invalid blob handle")
+	}
+	if err := r.blob.Reopen(rowId); err != nil {
+		return err
+	}
+	r.offset = 0
+	return nil
+}
+
 // Close closes the underlying blob.
 func (r *BlobStream) Close() error {
 	if r.blob != nil {
@@ -827,34 +1098,185 @@ func (w *BlobStream) Write(p []byte) (int, error) {
 	return toWrite, nil
 }
 
-type Transaction struct {
-	db       *Connection
-	finished bool
+// quoteIdentifier quotes name so it can be safely embedded as an SQL identifier.
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+const blobWriterInitialSize = 4096
+
+// blobWriterCopySize is the largest chunk BlobWriter holds in memory while
+// growing the BLOB.
+const blobWriterCopySize = 64 * 1024
+
+// blobWriterScratchTable is the temporary table where BlobWriter keeps the
+// bytes written so far while growing the BLOB.
+const blobWriterScratchTable = "goliat_blob_writer"
+
+// BlobWriter writes a BLOB of unknown length into a row. The column is
+// reallocated with a larger zeroblob as data arrives and truncated to the
+// number of bytes written on Close. While growing, the data written so far
+// is kept in the temporary table goliat_blob_writer.
+type BlobWriter struct {
+	db           *Connection
+	databaseName DatabaseName
+	tableName    string
+	columnName   string
+	rowId        int64
+	blob         *Blob
+	capacity     int
+	size         int
+	err          error
+}
+
+// NewBlobWriter replaces the value of the given column with an empty BLOB and
+// returns a writer that appends to it.
+func (d *Connection) NewBlobWriter(databaseName DatabaseName, tableName string, columnName string, rowId int64) (*BlobWriter, error) {
+	w := &BlobWriter{
+		db:           d,
+		databaseName: databaseName,
+		tableName:    tableName,
+		columnName:   columnName,
+		rowId:        rowId,
+	}
+	sql := fmt.Sprintf("UPDATE %s.%s SET %s = zeroblob(?) WHERE rowid = ?",
+		quoteIdentifier(string(databaseName)), quoteIdentifier(tableName), quoteIdentifier(columnName))
+	if err := d.Exec(sql, int64(blobWriterInitialSize), rowId); err != nil {
+		return nil, err
+	}
+	if err := w.open(); err != nil {
+		return nil, err
+	}
+	return w, nil
 }
 
-func (d *Connection) BeginTransaction() (*Transaction, error) {
-	err := d.Exec("BEGIN TRANSACTION;")
+func (w *BlobWriter) open() error {
+	blob, err := w.db.BlobOpen(w.databaseName, w.tableName, w.columnName, w.rowId, BlobOpenFlagsReadWrite)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	w.blob = blob
+	w.capacity = blob.Bytes()
+	return nil
+}
+
+// copyBlob copies the first n bytes of src to dst, a chunk at a time.
+func copyBlob(dst *Blob, src *Blob, n int) error {
+	for offset := 0; offset < n; offset += blobWriterCopySize {
+		data, err := src.Read(offset, min(blobWriterCopySize, n-offset))
+		if err != nil {
+			return err
+		}
+		if err := dst.Write(offset, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *BlobWriter) grow(required int) (err error) {
+	// Concatenating a zeroblob in SQL would load the whole BLOB in memory and
+	// go through text, which UTF-16 databases round to an even length. The
+	// bytes written so far are instead moved aside to a scratch row and
+	// copied back into a larger zeroblob, a chunk at a time.
+	if err := w.db.Exec("CREATE TEMP TABLE IF NOT EXISTS " + blobWriterScratchTable + " (data BLOB)"); err != nil {
+		return err
+	}
+	// The scratch row must not replace the rowid reported by
+	// LastInsertRowId, which callers typically pass to NewBlobWriter.
+	lastInsertRowId := C.sqlite3_last_insert_rowid(w.db.h.ptr)
+	err = w.db.Exec("INSERT INTO temp."+blobWriterScratchTable+" (data) VALUES (zeroblob(?))", int64(w.size))
+	scratchRowId := w.db.LastInsertRowId()
+	C.sqlite3_set_last_insert_rowid(w.db.h.ptr, lastInsertRowId)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = errors.Join(err, w.db.Exec("DELETE FROM temp."+blobWriterScratchTable+" WHERE rowid = ?", scratchRowId))
+	}()
+	scratch, err := w.db.BlobOpen(DatabaseNameTemp, blobWriterScratchTable, "data", scratchRowId, BlobOpenFlagsReadWrite)
+	if err != nil {
+		return err
+	}
+	defer scratch.Close()
+	if err := copyBlob(scratch, w.blob, w.size); err != nil {
+		return err
 	}
 
-	result := &Transaction{db: d, finished: false}
+	sql := fmt.Sprintf("UPDATE %s.%s SET %s = zeroblob(?) WHERE rowid = ?",
+		quoteIdentifier(string(w.databaseName)), quoteIdentifier(w.tableName), quoteIdentifier(w.columnName))
+	if err := w.db.Exec(sql, int64(max(2*w.capacity, required)), w.rowId); err != nil {
+		return err
+	}
+	// The update expires the handle; reopening it on the same row picks up
+	// the new size.
+	if err := w.blob.Reopen(w.rowId); err != nil {
+		return err
+	}
+	w.capacity = w.blob.Bytes()
+	return copyBlob(w.blob, scratch, w.size)
+}
 
-	runtime.AddCleanup(result, func(db *Connection) {
-		if !result.finished {
-			db.Exec("ROLLBACK;")
+// Write appends p to the BLOB, growing it when needed. After a failed write
+// the writer keeps returning the same error.
+func (w *BlobWriter) Write(p []byte) (int, error) {
+	if w.blob == nil {
+		return 0, newDatabaseError(ErrorCode(C.SQLITE_MISUSE), "invalid blob handle")
+	}
+	if w.err != nil {
+		return 0, w.err
+	}
+	if w.size+len(p) > w.capacity {
+		if w.err = w.grow(w.size + len(p)); w.err != nil {
+			return 0, w.err
 		}
-	}, result.db)
+	}
+	if w.err = w.blob.Write(w.size, p); w.err != nil {
+		return 0, w.err
+	}
+	w.size += len(p)
+	return len(p), nil
+}
 
-	return result, nil
+// Size returns the number of bytes written so far.
+func (w *BlobWriter) Size() int {
+	return w.size
+}
+
+// Close truncates the BLOB to the number of bytes written and releases the
+// underlying blob handle. It returns the error of a failed Write or of
+// releasing the handle, if any.
+func (w *BlobWriter) Close() error {
+	if w.blob == nil {
+		return nil
+	}
+	closeErr := w.blob.Close()
+	w.blob = nil
+	sql := fmt.Sprintf("UPDATE %s.%s SET %s = substr(%s, 1, ?) WHERE rowid = ?",
+		quoteIdentifier(string(w.databaseName)), quoteIdentifier(w.tableName), quoteIdentifier(w.columnName), quoteIdentifier(w.columnName))
+	return errors.Join(w.err, closeErr, w.db.Exec(sql, int64(w.size), w.rowId))
+}
+
+// Transaction is a transaction started with BeginTransaction. It stays open
+// until Commit or Rollback is called, or the connection is closed, which
+// rolls it back. Dropping a Transaction does not end it, since the
+// connection keeps running every later statement inside it.
+type Transaction struct {
+	db *Connection
+}
+
+func (d *Connection) BeginTransaction() (*Transaction, error) {
+	err := d.Exec("BEGIN TRANSACTION;")
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{db: d}, nil
 }
 
 func (t *Transaction) Commit() error {
-	t.finished = true
 	return t.db.Exec("COMMIT;")
 }
 
 func (t *Transaction) Rollback() error {
-	t.finished = true
 	return t.db.Exec("ROLLBACK;")
 }
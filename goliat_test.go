@@ -15,10 +15,16 @@
 package goliat_test
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
 	"io"
+	"path/filepath"
+	"runtime"
 	"strings"
 	"testing"
+	"unicode/utf16"
 
 	"github.com/filcuc/goliat"
 	"github.com/stretchr/testify/assert"
@@ -568,6 +574,33 @@ func TestTransactionCommit(t *testing.T) {
 	assert.Equal(t, 1, count)
 }
 
+func TestTransactionDropped(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := goliat.Open(path)
+	assert.NoError(t, err)
+
+	assert.NoError(t, db.Exec("CREATE TABLE foo (bar TEXT)"))
+	_, err = db.BeginTransaction()
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("INSERT INTO foo (bar) VALUES (?)", "baz"))
+
+	// Collecting the dropped transaction must not roll back behind the
+	// connection's back.
+	runtime.GC()
+	runtime.GC()
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	// Closing the connection rolls it back.
+	assert.NoError(t, db.Close())
+	db, err = goliat.Open(path)
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 0, count)
+}
+
 func TestQueryRowShouldErrorNotFoundIfNoResult(t *testing.T) {
 	db, err := goliat.Open(":memory:")
 	assert.NoError(t, err)
@@ -675,3 +708,328 @@ func TestBlobWrite(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, newData, picture)
 }
+
+func TestTextWithEmbeddedNul(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (bar TEXT)")
+	assert.NoError(t, err)
+
+	expected := "foo\x00bar"
+	err = db.Exec("INSERT INTO foo (bar) VALUES (?)", expected)
+	assert.NoError(t, err)
+
+	var length int
+	err = db.QueryRow("SELECT length(CAST(bar AS BLOB)) FROM foo").Scan(&length)
+	assert.NoError(t, err)
+	assert.Equal(t, len(expected), length)
+
+	var actual string
+	err = db.QueryRow("SELECT bar FROM foo").Scan(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+
+	var actualStruct CustomTypeTestSruct
+	err = db.Exec("UPDATE foo SET bar = ?", "a\x00b;c")
+	assert.NoError(t, err)
+	err = db.QueryRow("SELECT bar FROM foo").Scan(&actualStruct)
+	assert.NoError(t, err)
+	assert.Equal(t, "a\x00b", actualStruct.field1)
+}
+
+func TestBindEmptyText(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var isNull bool
+	err = db.QueryRow("SELECT ? IS NULL", "").Scan(&isNull)
+	assert.NoError(t, err)
+	assert.False(t, isNull)
+}
+
+func TestText16(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (bar TEXT)")
+	assert.NoError(t, err)
+
+	expected := goliat.Text16(utf16.Encode([]rune("héllo wörld")))
+	err = db.Exec("INSERT INTO foo (bar) VALUES (?)", expected)
+	assert.NoError(t, err)
+
+	var text string
+	err = db.QueryRow("SELECT bar FROM foo").Scan(&text)
+	assert.NoError(t, err)
+	assert.Equal(t, "héllo wörld", text)
+
+	var actual goliat.Text16
+	err = db.QueryRow("SELECT bar FROM foo").Scan(&actual)
+	assert.NoError(t, err)
+	assert.Equal(t, expected, actual)
+}
+
+func TestColumnViews(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (name TEXT, data BLOB)")
+	assert.NoError(t, err)
+	err = db.Exec("INSERT INTO foo (name, data) VALUES (?, ?)", "baz", []byte{0, 1, 2, 3})
+	assert.NoError(t, err)
+
+	rows, err := db.Query("SELECT name, data FROM foo")
+	assert.NoError(t, err)
+	defer rows.Close()
+	assert.True(t, rows.Next())
+
+	err = rows.ColumnTextView(0, func(text []byte) error {
+		assert.Equal(t, []byte("baz"), text)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	expectedErr := errors.New("stop")
+	err = rows.ColumnBlobView(1, func(data []byte) error {
+		assert.Equal(t, []byte{0, 1, 2, 3}, data)
+		return expectedErr
+	})
+	assert.ErrorIs(t, err, expectedErr)
+}
+
+func TestScanIntoWriter(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (data BLOB)")
+	assert.NoError(t, err)
+	expected := bytes.Repeat([]byte{1, 2, 3}, 1<<16)
+	err = db.Exec("INSERT INTO foo (data) VALUES (?)", expected)
+	assert.NoError(t, err)
+
+	hash := sha256.New()
+	err = db.QueryRow("SELECT data FROM foo").Scan(hash)
+	assert.NoError(t, err)
+	expectedHash := sha256.Sum256(expected)
+	assert.Equal(t, expectedHash[:], hash.Sum(nil))
+}
+
+func TestBlobWriter(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE User (name TEXT, picture BLOB)")
+	assert.NoError(t, err)
+	err = db.Exec("INSERT INTO User (name) VALUES (?)", "foo")
+	assert.NoError(t, err)
+
+	writer, err := db.NewBlobWriter(goliat.DatabaseNameMain, "User", "picture", db.LastInsertRowId())
+	assert.NoError(t, err)
+
+	expectedData := bytes.Repeat([]byte{1, 2, 3, 4, 5, 6, 7}, 10000)
+	n, err := io.Copy(writer, bytes.NewReader(expectedData))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(expectedData)), n)
+	assert.NoError(t, writer.Close())
+
+	var picture []byte
+	err = db.QueryRow("SELECT picture FROM User WHERE name = ?", "foo").Scan(&picture)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedData, picture)
+}
+
+func TestBlobWriterLarge(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Exec("CREATE TABLE User (name TEXT, picture BLOB)"))
+	assert.NoError(t, db.Exec("INSERT INTO User (name) VALUES (?)", "foo"))
+	rowId := db.LastInsertRowId()
+
+	writer, err := db.NewBlobWriter(goliat.DatabaseNameMain, "User", "picture", rowId)
+	assert.NoError(t, err)
+	var expectedData []byte
+	for i := range 1000 {
+		chunk := bytes.Repeat([]byte{byte(i)}, 333)
+		expectedData = append(expectedData, chunk...)
+		_, err = writer.Write(chunk)
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, writer.Close())
+	// Growing the BLOB leaves the last inserted rowid alone.
+	assert.Equal(t, rowId, db.LastInsertRowId())
+
+	var picture []byte
+	assert.NoError(t, db.QueryRow("SELECT picture FROM User WHERE rowid = ?", rowId).Scan(&picture))
+	assert.Equal(t, expectedData, picture)
+}
+
+func TestBlobWriterUTF16(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Exec("PRAGMA encoding = 'UTF-16le'"))
+	assert.NoError(t, db.Exec("CREATE TABLE User (name TEXT, picture BLOB)"))
+	assert.NoError(t, db.Exec("INSERT INTO User (name) VALUES (?)", "foo"))
+
+	writer, err := db.NewBlobWriter(goliat.DatabaseNameMain, "User", "picture", db.LastInsertRowId())
+	assert.NoError(t, err)
+
+	// Odd sizes must survive growing the BLOB in a UTF-16 database.
+	expectedData := append(bytes.Repeat([]byte{1, 2, 3, 4}, 2500), 5)
+	_, err = writer.Write(expectedData)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	var picture []byte
+	err = db.QueryRow("SELECT picture FROM User WHERE name = ?", "foo").Scan(&picture)
+	assert.NoError(t, err)
+	assert.Equal(t, expectedData, picture)
+}
+
+func TestBlobWriterError(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Exec("CREATE TABLE User (name TEXT, picture BLOB)"))
+	assert.NoError(t, db.Exec("INSERT INTO User (name) VALUES (?)", "foo"))
+
+	writer, err := db.NewBlobWriter(goliat.DatabaseNameMain, "User", "picture", db.LastInsertRowId())
+	assert.NoError(t, err)
+	_, err = writer.Write([]byte{1, 2, 3})
+	assert.NoError(t, err)
+
+	// Changing the row expires the blob handle, so the data cannot be
+	// written anymore and Close reports it.
+	assert.NoError(t, db.Exec("UPDATE User SET name = 'bar'"))
+	_, err = writer.Write([]byte{4, 5, 6})
+	assert.Error(t, err)
+	_, err = writer.Write([]byte{7})
+	assert.Error(t, err)
+	assert.Error(t, writer.Close())
+}
+
+func TestBlobWriterEmpty(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE User (name TEXT, picture BLOB)")
+	assert.NoError(t, err)
+	err = db.Exec("INSERT INTO User (name, picture) VALUES (?, ?)", "foo", []byte{1, 2, 3})
+	assert.NoError(t, err)
+
+	writer, err := db.NewBlobWriter(goliat.DatabaseNameMain, "User", "picture", db.LastInsertRowId())
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Close())
+
+	var length int
+	var kind string
+	err = db.QueryRow("SELECT length(picture), typeof(picture) FROM User").Scan(&length, &kind)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, length)
+	assert.Equal(t, "blob", kind)
+}
+
+func TestBlobReopen(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE User (name TEXT, picture BLOB)")
+	assert.NoError(t, err)
+	err = db.Exec("INSERT INTO User (name, picture) VALUES (?, ?), (?, ?)", "foo", []byte{0, 1, 2, 3}, "bar", []byte{4, 5})
+	assert.NoError(t, err)
+
+	blob, err := db.BlobOpen(goliat.DatabaseNameMain, "User", "picture", 1, goliat.BlobOpenFlagsReadOnly)
+	assert.NoError(t, err)
+
+	stream := goliat.NewBlobStream(blob)
+	defer stream.Close()
+
+	actualData, err := io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{0, 1, 2, 3}, actualData)
+
+	assert.NoError(t, stream.Reopen(2))
+	actualData, err = io.ReadAll(stream)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{4, 5}, actualData)
+
+	assert.Error(t, stream.Reopen(3))
+}
+
+func TestExecScript(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.ExecScript(`
+		CREATE TABLE foo (bar TEXT);
+		INSERT INTO foo (bar) VALUES ('baz');
+		INSERT INTO foo (bar) VALUES ('qux');
+	`)
+	assert.NoError(t, err)
+
+	var count int
+	err = db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = db.ExecScript("INSERT INTO foo (bar) VALUES ('quux'); foo")
+	var dbErr *goliat.DatabaseError
+	assert.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, goliat.ERROR, dbErr.Code)
+	assert.Contains(t, dbErr.Message, "syntax error")
+}
+
+func TestExecReturning(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT NOT NULL, created TEXT DEFAULT 'now')")
+	assert.NoError(t, err)
+
+	rows, err := db.ExecReturning("INSERT INTO foo (bar) VALUES (?), (?) RETURNING id, created", "a", "b")
+	assert.NoError(t, err)
+	var ids []int
+	for rows.Next() {
+		var id int
+		var created string
+		assert.NoError(t, rows.Scan(&id, &created))
+		assert.Equal(t, "now", created)
+		ids = append(ids, id)
+	}
+	assert.NoError(t, rows.Err())
+	assert.NoError(t, rows.Close())
+	assert.Equal(t, []int{1, 2}, ids)
+
+	// The changes are made even when the rows are not read.
+	rows, err = db.ExecReturning("DELETE FROM foo WHERE id = ? RETURNING bar", 1)
+	assert.NoError(t, err)
+	assert.NoError(t, rows.Close())
+	var count int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 1, count)
+
+	rows, err = db.ExecReturning("UPDATE foo SET bar = 'c' WHERE id = 42 RETURNING id")
+	assert.NoError(t, err)
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Close())
+
+	_, err = db.ExecReturning("INSERT INTO foo (bar) VALUES (NULL) RETURNING id")
+	var dbErr *goliat.DatabaseError
+	assert.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, goliat.CONSTRAINT, dbErr.Code)
+}
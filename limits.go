@@ -0,0 +1,96 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+
+// sqlite3_db_config is variadic and cannot be called from Go directly.
+static inline int goliat_db_config_bool(sqlite3 *db, int op, int value, int *result) {
+	return sqlite3_db_config(db, op, value, result);
+}
+*/
+import "C"
+
+// Limit identifies a run-time limit of a connection.
+type Limit int
+
+const (
+	LimitLength            Limit = C.SQLITE_LIMIT_LENGTH
+	LimitSQLLength         Limit = C.SQLITE_LIMIT_SQL_LENGTH
+	LimitColumn            Limit = C.SQLITE_LIMIT_COLUMN
+	LimitExprDepth         Limit = C.SQLITE_LIMIT_EXPR_DEPTH
+	LimitCompoundSelect    Limit = C.SQLITE_LIMIT_COMPOUND_SELECT
+	LimitVDBEOp            Limit = C.SQLITE_LIMIT_VDBE_OP
+	LimitFunctionArg       Limit = C.SQLITE_LIMIT_FUNCTION_ARG
+	LimitAttached          Limit = C.SQLITE_LIMIT_ATTACHED
+	LimitLikePatternLength Limit = C.SQLITE_LIMIT_LIKE_PATTERN_LENGTH
+	LimitVariableNumber    Limit = C.SQLITE_LIMIT_VARIABLE_NUMBER
+	LimitTriggerDepth      Limit = C.SQLITE_LIMIT_TRIGGER_DEPTH
+	LimitWorkerThreads     Limit = C.SQLITE_LIMIT_WORKER_THREADS
+)
+
+// SetLimit changes the given limit and returns its previous value. A negative
+// value leaves the limit unchanged, so SetLimit(limit, -1) reads it. Values
+// above the compile-time maximum are silently truncated.
+func (d *Connection) SetLimit(limit Limit, value int) int {
+	return int(C.sqlite3_limit(d.h.ptr, C.int(limit), C.int(value)))
+}
+
+// DBConfig identifies a boolean connection option set with sqlite3_db_config.
+type DBConfig int
+
+const (
+	DBConfigEnableFKey          DBConfig = C.SQLITE_DBCONFIG_ENABLE_FKEY
+	DBConfigEnableTrigger       DBConfig = C.SQLITE_DBCONFIG_ENABLE_TRIGGER
+	DBConfigEnableFTS3Tokenizer DBConfig = C.SQLITE_DBCONFIG_ENABLE_FTS3_TOKENIZER
+	DBConfigEnableLoadExtension DBConfig = C.SQLITE_DBCONFIG_ENABLE_LOAD_EXTENSION
+	DBConfigNoCkptOnClose       DBConfig = C.SQLITE_DBCONFIG_NO_CKPT_ON_CLOSE
+	DBConfigEnableQPSG          DBConfig = C.SQLITE_DBCONFIG_ENABLE_QPSG
+	DBConfigTriggerEQP          DBConfig = C.SQLITE_DBCONFIG_TRIGGER_EQP
+	DBConfigResetDatabase       DBConfig = C.SQLITE_DBCONFIG_RESET_DATABASE
+	DBConfigDefensive           DBConfig = C.SQLITE_DBCONFIG_DEFENSIVE
+	DBConfigWritableSchema      DBConfig = C.SQLITE_DBCONFIG_WRITABLE_SCHEMA
+	DBConfigLegacyAlterTable    DBConfig = C.SQLITE_DBCONFIG_LEGACY_ALTER_TABLE
+	DBConfigDQSDML              DBConfig = C.SQLITE_DBCONFIG_DQS_DML
+	DBConfigDQSDDL              DBConfig = C.SQLITE_DBCONFIG_DQS_DDL
+	DBConfigEnableView          DBConfig = C.SQLITE_DBCONFIG_ENABLE_VIEW
+	DBConfigLegacyFileFormat    DBConfig = C.SQLITE_DBCONFIG_LEGACY_FILE_FORMAT
+	DBConfigTrustedSchema       DBConfig = C.SQLITE_DBCONFIG_TRUSTED_SCHEMA
+	DBConfigStmtScanStatus      DBConfig = C.SQLITE_DBCONFIG_STMT_SCANSTATUS
+	DBConfigReverseScanOrder    DBConfig = C.SQLITE_DBCONFIG_REVERSE_SCANORDER
+	DBConfigEnableAttachCreate  DBConfig = C.SQLITE_DBCONFIG_ENABLE_ATTACH_CREATE
+	DBConfigEnableAttachWrite   DBConfig = C.SQLITE_DBCONFIG_ENABLE_ATTACH_WRITE
+	DBConfigEnableComments      DBConfig = C.SQLITE_DBCONFIG_ENABLE_COMMENTS
+)
+
+func (d *Connection) dbConfig(config DBConfig, value int) (bool, error) {
+	var result C.int
+	if ec := C.goliat_db_config_bool(d.h.ptr, C.int(config), C.int(value), &result); ec != C.SQLITE_OK {
+		return false, d.newDatabaseError()
+	}
+	return result != 0, nil
+}
+
+// SetConfig enables or disables a connection option.
+func (d *Connection) SetConfig(config DBConfig, enable bool) error {
+	_, err := d.dbConfig(config, boolToInt(enable))
+	return err
+}
+
+// Config reports whether a connection option is enabled.
+func (d *Connection) Config(config DBConfig) (bool, error) {
+	return d.dbConfig(config, -1)
+}
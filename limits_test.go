@@ -0,0 +1,64 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetLimit(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	previous := db.SetLimit(goliat.LimitSQLLength, 100)
+	assert.Greater(t, previous, 100)
+	assert.Equal(t, 100, db.SetLimit(goliat.LimitSQLLength, -1))
+
+	assert.NoError(t, db.Exec("SELECT 1"))
+	assert.Error(t, db.Exec("SELECT '"+strings.Repeat("x", 100)+"'"))
+
+	db.SetLimit(goliat.LimitAttached, 0)
+	assert.Error(t, db.Exec("ATTACH ':memory:' AS aux"))
+}
+
+func TestSetConfig(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.SetConfig(goliat.DBConfigEnableFKey, true))
+	enabled, err := db.Config(goliat.DBConfigEnableFKey)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	err = db.Exec("CREATE TABLE parent (id INTEGER PRIMARY KEY)")
+	assert.NoError(t, err)
+	err = db.Exec("CREATE TABLE child (parent INTEGER REFERENCES parent (id))")
+	assert.NoError(t, err)
+	assert.Error(t, db.Exec("INSERT INTO child (parent) VALUES (1)"))
+
+	assert.NoError(t, db.SetConfig(goliat.DBConfigDQSDML, false))
+	assert.Error(t, db.Exec(`SELECT "not a column"`))
+
+	assert.NoError(t, db.SetConfig(goliat.DBConfigDefensive, true))
+	enabled, err = db.Config(goliat.DBConfigDefensive)
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+}
@@ -0,0 +1,227 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// memoryData is the content of a file of a memory VFS with the state of the
+// locks held on it by all the connections.
+type memoryData struct {
+	data      []byte
+	shared    int
+	reserved  bool
+	pending   bool
+	exclusive bool
+}
+
+type memoryVFS struct {
+	mu        sync.Mutex
+	files     map[string]*memoryData
+	tempFiles int
+}
+
+// NewMemoryVFS returns a VFS keeping all its files in memory. Files live as
+// long as the VFS and are shared by all the connections using it, which
+// follow the usual SQLite locking rules.
+func NewMemoryVFS() VFS {
+	return &memoryVFS{files: make(map[string]*memoryData)}
+}
+
+func (v *memoryVFS) Open(name string, flags OpenFlag) (File, OpenFlag, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if name == "" {
+		v.tempFiles++
+		name = fmt.Sprintf("goliat-temp-%d", v.tempFiles)
+		flags |= OpenFlagCreate | OpenFlagDeleteOnClose
+	}
+
+	data, ok := v.files[name]
+	switch {
+	case ok && flags&OpenFlagExclusive != 0:
+		return nil, 0, newDatabaseError(CANTOPEN, fmt.Sprintf("file %q already exists", name))
+	case !ok && flags&OpenFlagCreate == 0:
+		return nil, 0, newDatabaseError(CANTOPEN, fmt.Sprintf("file %q does not exist", name))
+	case !ok:
+		data = &memoryData{}
+		v.files[name] = data
+	}
+	return &memoryFile{vfs: v, name: name, data: data, deleteOnClose: flags&OpenFlagDeleteOnClose != 0}, flags, nil
+}
+
+func (v *memoryVFS) Delete(name string, syncDir bool) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.files[name]; !ok {
+		return &fs.PathError{Op: "delete", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(v.files, name)
+	return nil
+}
+
+func (v *memoryVFS) Access(name string, flags AccessFlag) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.files[name]
+	return ok, nil
+}
+
+func (v *memoryVFS) FullPathname(name string) (string, error) {
+	return name, nil
+}
+
+type memoryFile struct {
+	vfs           *memoryVFS
+	name          string
+	data          *memoryData
+	lock          LockLevel
+	reserved      bool
+	deleteOnClose bool
+}
+
+func (f *memoryFile) Close() error {
+	if err := f.Unlock(LockLevelNone); err != nil {
+		return err
+	}
+	if f.deleteOnClose {
+		f.vfs.mu.Lock()
+		if f.vfs.files[f.name] == f.data {
+			delete(f.vfs.files, f.name)
+		}
+		f.vfs.mu.Unlock()
+	}
+	return nil
+}
+
+func (f *memoryFile) ReadAt(p []byte, off int64) (int, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if off >= int64(len(f.data.data)) {
+		return 0, io.EOF
+	}
+	n := copy(p, f.data.data[off:])
+	if n < len(p) {
+		return n, io.EOF
+	}
+	return n, nil
+}
+
+func (f *memoryFile) WriteAt(p []byte, off int64) (int, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if end := off + int64(len(p)); end > int64(len(f.data.data)) {
+		f.data.data = append(f.data.data, make([]byte, end-int64(len(f.data.data)))...)
+	}
+	return copy(f.data.data[off:], p), nil
+}
+
+func (f *memoryFile) Truncate(size int64) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if size < int64(len(f.data.data)) {
+		f.data.data = f.data.data[:size]
+	}
+	return nil
+}
+
+func (f *memoryFile) Sync(flags SyncFlag) error {
+	return nil
+}
+
+func (f *memoryFile) FileSize() (int64, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	return int64(len(f.data.data)), nil
+}
+
+func (f *memoryFile) Lock(level LockLevel) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if level <= f.lock {
+		return nil
+	}
+	busy := newDatabaseError(BUSY, "database is locked")
+	switch level {
+	case LockLevelShared:
+		if f.data.pending || f.data.exclusive {
+			return busy
+		}
+		f.data.shared++
+	case LockLevelReserved:
+		if f.data.reserved {
+			return busy
+		}
+		f.data.reserved = true
+		f.reserved = true
+	case LockLevelExclusive:
+		// A pending lock keeps new readers out while waiting for the
+		// current ones to finish.
+		if f.lock < LockLevelPending {
+			if f.data.pending {
+				return busy
+			}
+			f.data.pending = true
+			f.lock = LockLevelPending
+		}
+		if f.data.shared > 1 {
+			return busy
+		}
+		f.data.exclusive = true
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *memoryFile) Unlock(level LockLevel) error {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	if level >= f.lock {
+		return nil
+	}
+	if f.reserved {
+		f.data.reserved = false
+		f.reserved = false
+	}
+	if f.lock >= LockLevelPending {
+		f.data.pending = false
+	}
+	if f.lock == LockLevelExclusive {
+		f.data.exclusive = false
+	}
+	if level == LockLevelNone {
+		f.data.shared--
+	}
+	f.lock = level
+	return nil
+}
+
+func (f *memoryFile) CheckReservedLock() (bool, error) {
+	f.vfs.mu.Lock()
+	defer f.vfs.mu.Unlock()
+	return f.data.reserved || f.data.pending || f.data.exclusive, nil
+}
+
+func (f *memoryFile) SectorSize() int {
+	return 4096
+}
+
+func (f *memoryFile) DeviceCharacteristics() DeviceCharacteristic {
+	return DeviceCharacteristicPowersafeOverwrite
+}
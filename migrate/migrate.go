@@ -0,0 +1,424 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package migrate applies ordered, versioned schema migrations to a goliat
+// connection. Each migration runs in its own transaction together with the
+// update of the recorded schema version.
+//
+// The version is stored in PRAGMA user_version by default. When
+// Options.HistoryTable is set, applied migrations are recorded in that table
+// together with a checksum, which allows detecting migrations that were
+// edited after being applied. Pending migrations are then those missing from
+// the table, so a migration merged late with a lower version than the latest
+// applied one still runs.
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/filcuc/goliat"
+)
+
+// Migration is a single schema change identified by a unique positive version.
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(conn *goliat.Connection) error
+	// Down reverts Up. Migrations without Down cannot be rolled back.
+	Down func(conn *goliat.Connection) error
+	// Checksum identifies the content of the migration. It is computed for
+	// SQL migrations and optional for Go ones.
+	Checksum string
+}
+
+// SQL returns a migration running the given SQL scripts. An empty down script
+// makes the migration irreversible.
+func SQL(version int, name string, up string, down string) Migration {
+	sum := sha256.Sum256([]byte(up))
+	m := Migration{
+		Version:  version,
+		Name:     name,
+		Checksum: hex.EncodeToString(sum[:]),
+		Up: func(conn *goliat.Connection) error {
+			return conn.ExecScript(up)
+		},
+	}
+	if down != "" {
+		m.Down = func(conn *goliat.Connection) error {
+			return conn.ExecScript(down)
+		}
+	}
+	return m
+}
+
+var fileNamePattern = regexp.MustCompile(`^(\d+)_(.+?)(\.up|\.down)?\.sql$`)
+
+// FromFS loads SQL migrations from the files of dir in fsys. Files are named
+// VERSION_NAME.sql or VERSION_NAME.up.sql, with an optional matching
+// VERSION_NAME.down.sql. Other files are ignored.
+func FromFS(fsys fs.FS, dir string) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	type scripts struct {
+		name     string
+		up, down string
+		hasUp    bool
+	}
+	byVersion := make(map[int]*scripts)
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		match := fileNamePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %s: %w", entry.Name(), err)
+		}
+		content, err := fs.ReadFile(fsys, path.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		s := byVersion[version]
+		if s == nil {
+			s = &scripts{name: match[2]}
+			byVersion[version] = s
+		} else if s.name != match[2] {
+			return nil, fmt.Errorf("migration %d has conflicting names %q and %q", version, s.name, match[2])
+		}
+		if match[3] == ".down" {
+			s.down = string(content)
+		} else {
+			if s.hasUp {
+				return nil, fmt.Errorf("migration %d has more than one up script", version)
+			}
+			s.up = string(content)
+			s.hasUp = true
+		}
+	}
+
+	var migrations []Migration
+	for version, s := range byVersion {
+		if !s.hasUp {
+			return nil, fmt.Errorf("migration %d has no up script", version)
+		}
+		migrations = append(migrations, SQL(version, s.name, s.up, s.down))
+	}
+	slices.SortFunc(migrations, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	return migrations, nil
+}
+
+type Options struct {
+	// HistoryTable records applied migrations in the named table instead
+	// of PRAGMA user_version. It is required for checksum verification.
+	HistoryTable string
+	// DryRun makes Up and DownTo return the migrations they would run
+	// without running them.
+	DryRun bool
+}
+
+// ChecksumMismatch describes an applied migration whose checksum changed.
+type ChecksumMismatch struct {
+	Version  int
+	Name     string
+	Applied  string
+	Expected string
+}
+
+// DriftError is returned when applied migrations no longer match the
+// migrations known to the Migrator.
+type DriftError struct {
+	Mismatches []ChecksumMismatch
+	// Unknown lists applied versions without a matching migration.
+	Unknown []int
+}
+
+func (e *DriftError) Error() string {
+	var parts []string
+	for _, m := range e.Mismatches {
+		parts = append(parts, fmt.Sprintf("migration %d (%s) checksum %s != %s", m.Version, m.Name, m.Applied, m.Expected))
+	}
+	for _, version := range e.Unknown {
+		parts = append(parts, fmt.Sprintf("unknown applied migration %d", version))
+	}
+	return "schema drift: " + strings.Join(parts, "; ")
+}
+
+type Migrator struct {
+	db         *goliat.Connection
+	migrations []Migration
+	opts       Options
+}
+
+// New validates migrations and returns a Migrator for db. When a history
+// table is configured it is created if needed.
+func New(db *goliat.Connection, migrations []Migration, opts Options) (*Migrator, error) {
+	sorted := slices.Clone(migrations)
+	slices.SortFunc(sorted, func(a, b Migration) int {
+		return a.Version - b.Version
+	})
+	for i, m := range sorted {
+		if m.Version <= 0 {
+			return nil, fmt.Errorf("migration %q has non-positive version %d", m.Name, m.Version)
+		}
+		if m.Up == nil {
+			return nil, fmt.Errorf("migration %d has no up step", m.Version)
+		}
+		if i > 0 && sorted[i-1].Version == m.Version {
+			return nil, fmt.Errorf("duplicate migration version %d", m.Version)
+		}
+	}
+
+	if opts.HistoryTable != "" {
+		err := db.Exec(fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			applied_at TEXT NOT NULL DEFAULT CURRENT_TIMESTAMP
+		)`, quoteIdentifier(opts.HistoryTable)))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Migrator{db: db, migrations: sorted, opts: opts}, nil
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// Version returns the version of the last applied migration, or 0.
+func (m *Migrator) Version() (int, error) {
+	if m.opts.HistoryTable == "" {
+		return m.db.Pragmas().UserVersion()
+	}
+	var version int
+	err := m.db.QueryRow(fmt.Sprintf("SELECT COALESCE(MAX(version), 0) FROM %s", quoteIdentifier(m.opts.HistoryTable))).Scan(&version)
+	return version, err
+}
+
+// Verify compares the checksums recorded in the history table with the
+// migrations known to the Migrator and returns a *DriftError on mismatch.
+// It always succeeds when no history table is configured.
+func (m *Migrator) Verify() error {
+	if m.opts.HistoryTable == "" {
+		return nil
+	}
+
+	rows, err := m.db.Query(fmt.Sprintf("SELECT version, checksum FROM %s ORDER BY version", quoteIdentifier(m.opts.HistoryTable)))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	drift := &DriftError{}
+	for rows.Next() {
+		var version int
+		var checksum string
+		if err := rows.Scan(&version, &checksum); err != nil {
+			return err
+		}
+		i, found := slices.BinarySearchFunc(m.migrations, version, func(m Migration, version int) int {
+			return m.Version - version
+		})
+		if !found {
+			drift.Unknown = append(drift.Unknown, version)
+			continue
+		}
+		if expected := m.migrations[i].Checksum; expected != checksum {
+			drift.Mismatches = append(drift.Mismatches, ChecksumMismatch{
+				Version:  version,
+				Name:     m.migrations[i].Name,
+				Applied:  checksum,
+				Expected: expected,
+			})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if len(drift.Mismatches) > 0 || len(drift.Unknown) > 0 {
+		return drift
+	}
+	return nil
+}
+
+// Up applies all pending migrations and returns them.
+func (m *Migrator) Up() ([]Migration, error) {
+	return m.UpTo(m.latest())
+}
+
+// UpTo applies pending migrations up to and including version.
+func (m *Migrator) UpTo(version int) ([]Migration, error) {
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range m.migrations {
+		if !applied(migration.Version) && migration.Version <= version {
+			pending = append(pending, migration)
+		}
+	}
+	if m.opts.DryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		err := m.apply(migration.Up, func() error {
+			return m.recordUp(migration)
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// DownTo reverts applied migrations newer than version, newest first, and
+// returns them in the order they were reverted.
+func (m *Migrator) DownTo(version int) ([]Migration, error) {
+	if err := m.Verify(); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, migration := range slices.Backward(m.migrations) {
+		if applied(migration.Version) && migration.Version > version {
+			if migration.Down == nil {
+				return nil, fmt.Errorf("migration %d (%s) cannot be reverted", migration.Version, migration.Name)
+			}
+			pending = append(pending, migration)
+		}
+	}
+	if m.opts.DryRun {
+		return pending, nil
+	}
+
+	for i, migration := range pending {
+		err := m.apply(migration.Down, func() error {
+			return m.recordDown(migration)
+		})
+		if err != nil {
+			return pending[:i], fmt.Errorf("migration %d (%s): %w", migration.Version, migration.Name, err)
+		}
+	}
+	return pending, nil
+}
+
+// applied returns a function reporting whether the migration with the given
+// version is applied. Without a history table these are all the migrations up
+// to the current version.
+func (m *Migrator) applied() (func(version int) bool, error) {
+	if m.opts.HistoryTable == "" {
+		current, err := m.Version()
+		if err != nil {
+			return nil, err
+		}
+		return func(version int) bool {
+			return version <= current
+		}, nil
+	}
+
+	rows, err := m.db.Query(fmt.Sprintf("SELECT version FROM %s", quoteIdentifier(m.opts.HistoryTable)))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	versions := make(map[int]bool)
+	for rows.Next() {
+		var version int
+		if err := rows.Scan(&version); err != nil {
+			return nil, err
+		}
+		versions[version] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return func(version int) bool {
+		return versions[version]
+	}, nil
+}
+
+func (m *Migrator) latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+func (m *Migrator) apply(step func(conn *goliat.Connection) error, record func() error) error {
+	tx, err := m.db.BeginTransaction()
+	if err != nil {
+		return err
+	}
+	if err := step(m.db); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	if err := record(); err != nil {
+		return errors.Join(err, tx.Rollback())
+	}
+	return tx.Commit()
+}
+
+func (m *Migrator) recordUp(migration Migration) error {
+	if m.opts.HistoryTable == "" {
+		return m.db.Pragmas().SetUserVersion(migration.Version)
+	}
+	return m.db.Exec(fmt.Sprintf("INSERT INTO %s (version, name, checksum) VALUES (?, ?, ?)", quoteIdentifier(m.opts.HistoryTable)),
+		migration.Version, migration.Name, migration.Checksum)
+}
+
+func (m *Migrator) recordDown(migration Migration) error {
+	if m.opts.HistoryTable == "" {
+		// Migrations are applied in order, so the previous version is the
+		// closest older migration.
+		previous := 0
+		for _, other := range m.migrations {
+			if other.Version < migration.Version {
+				previous = other.Version
+			}
+		}
+		return m.db.Pragmas().SetUserVersion(previous)
+	}
+	return m.db.Exec(fmt.Sprintf("DELETE FROM %s WHERE version = ?", quoteIdentifier(m.opts.HistoryTable)), migration.Version)
+}
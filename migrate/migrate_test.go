@@ -0,0 +1,218 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package migrate_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/filcuc/goliat"
+	"github.com/filcuc/goliat/migrate"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFS = fstest.MapFS{
+	"migrations/0001_create_users.up.sql":   {Data: []byte("CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);")},
+	"migrations/0001_create_users.down.sql": {Data: []byte("DROP TABLE users;")},
+	"migrations/0002_add_email.up.sql":      {Data: []byte("ALTER TABLE users ADD COLUMN email TEXT; CREATE INDEX users_email ON users (email);")},
+	"migrations/0002_add_email.down.sql":    {Data: []byte("DROP INDEX users_email; ALTER TABLE users DROP COLUMN email;")},
+	"migrations/README.md":                  {Data: []byte("ignored")},
+}
+
+func tableExists(t *testing.T, db *goliat.Connection, name string) bool {
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM sqlite_schema WHERE type = 'table' AND name = ?", name).Scan(&count)
+	assert.NoError(t, err)
+	return count > 0
+}
+
+func TestFromFS(t *testing.T) {
+	migrations, err := migrate.FromFS(testFS, "migrations")
+	assert.NoError(t, err)
+	assert.Len(t, migrations, 2)
+	assert.Equal(t, 1, migrations[0].Version)
+	assert.Equal(t, "create_users", migrations[0].Name)
+	assert.NotNil(t, migrations[0].Down)
+	assert.Equal(t, 2, migrations[1].Version)
+	assert.NotEmpty(t, migrations[1].Checksum)
+}
+
+func TestUpAndDownWithUserVersion(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := migrate.FromFS(testFS, "migrations")
+	assert.NoError(t, err)
+	migrator, err := migrate.New(db, migrations, migrate.Options{})
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, applied, 2)
+	version, err := db.Pragmas().UserVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, version)
+	assert.NoError(t, db.Exec("INSERT INTO users (name, email) VALUES (?, ?)", "foo", "foo@example.com"))
+
+	applied, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.Empty(t, applied)
+
+	reverted, err := migrator.DownTo(1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	version, err = migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+	assert.Error(t, db.Exec("SELECT email FROM users"))
+
+	reverted, err = migrator.DownTo(0)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 1)
+	assert.False(t, tableExists(t, db, "users"))
+}
+
+func TestGoMigrationFailureRollsBack(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	expectedErr := errors.New("boom")
+	migrator, err := migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "create_foo", "CREATE TABLE foo (bar TEXT);", ""),
+		{
+			Version: 2,
+			Name:    "fail",
+			Up: func(conn *goliat.Connection) error {
+				if err := conn.Exec("CREATE TABLE baz (qux TEXT)"); err != nil {
+					return err
+				}
+				return expectedErr
+			},
+		},
+	}, migrate.Options{})
+	assert.NoError(t, err)
+
+	applied, err := migrator.Up()
+	assert.ErrorIs(t, err, expectedErr)
+	assert.Len(t, applied, 1)
+	assert.True(t, tableExists(t, db, "foo"))
+	assert.False(t, tableExists(t, db, "baz"))
+
+	version, err := migrator.Version()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, version)
+
+	_, err = migrator.DownTo(0)
+	assert.Error(t, err)
+}
+
+func TestHistoryTableDetectsDrift(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	opts := migrate.Options{HistoryTable: "schema_migrations"}
+	migrator, err := migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "create_foo", "CREATE TABLE foo (bar TEXT);", "DROP TABLE foo;"),
+	}, opts)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+	assert.NoError(t, migrator.Verify())
+
+	userVersion, err := db.Pragmas().UserVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, userVersion)
+
+	migrator, err = migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "create_foo", "CREATE TABLE foo (bar TEXT, baz TEXT);", "DROP TABLE foo;"),
+		migrate.SQL(2, "create_qux", "CREATE TABLE qux (bar TEXT);", ""),
+	}, opts)
+	assert.NoError(t, err)
+
+	_, err = migrator.Up()
+	var drift *migrate.DriftError
+	assert.True(t, errors.As(err, &drift))
+	assert.Len(t, drift.Mismatches, 1)
+	assert.Equal(t, 1, drift.Mismatches[0].Version)
+	assert.False(t, tableExists(t, db, "qux"))
+}
+
+func TestHistoryTableAppliesLateMigrations(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	opts := migrate.Options{HistoryTable: "schema_migrations"}
+	migrator, err := migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "create_foo", "CREATE TABLE foo (bar TEXT);", "DROP TABLE foo;"),
+		migrate.SQL(3, "create_baz", "CREATE TABLE baz (bar TEXT);", "DROP TABLE baz;"),
+	}, opts)
+	assert.NoError(t, err)
+	_, err = migrator.Up()
+	assert.NoError(t, err)
+
+	// Migration 2 was merged after 3 had already been applied.
+	migrator, err = migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "create_foo", "CREATE TABLE foo (bar TEXT);", "DROP TABLE foo;"),
+		migrate.SQL(2, "create_qux", "CREATE TABLE qux (bar TEXT);", "DROP TABLE qux;"),
+		migrate.SQL(3, "create_baz", "CREATE TABLE baz (bar TEXT);", "DROP TABLE baz;"),
+	}, opts)
+	assert.NoError(t, err)
+	applied, err := migrator.Up()
+	assert.NoError(t, err)
+	if assert.Len(t, applied, 1) {
+		assert.Equal(t, 2, applied[0].Version)
+	}
+	assert.True(t, tableExists(t, db, "qux"))
+
+	reverted, err := migrator.DownTo(1)
+	assert.NoError(t, err)
+	assert.Len(t, reverted, 2)
+	assert.False(t, tableExists(t, db, "qux"))
+	assert.False(t, tableExists(t, db, "baz"))
+	assert.True(t, tableExists(t, db, "foo"))
+}
+
+func TestDryRun(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	migrations, err := migrate.FromFS(testFS, "migrations")
+	assert.NoError(t, err)
+	migrator, err := migrate.New(db, migrations, migrate.Options{DryRun: true})
+	assert.NoError(t, err)
+
+	pending, err := migrator.Up()
+	assert.NoError(t, err)
+	assert.Len(t, pending, 2)
+	assert.False(t, tableExists(t, db, "users"))
+}
+
+func TestNewRejectsDuplicateVersions(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	_, err = migrate.New(db, []migrate.Migration{
+		migrate.SQL(1, "a", "SELECT 1;", ""),
+		migrate.SQL(1, "b", "SELECT 1;", ""),
+	}, migrate.Options{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,249 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrPoolClosed = errors.New("pool is closed")
+
+const defaultPoolReaders = 4
+
+type PoolOptions struct {
+	// Readers is the number of reader connections. Defaults to 4.
+	Readers int
+	// Init is called on every new connection, for example to set pragmas or
+	// register functions.
+	Init func(conn *Connection) error
+	// HealthCheck is called before a connection is handed out. A connection
+	// failing the check is closed and replaced.
+	HealthCheck func(conn *Connection) error
+	// MaxIdleTime closes connections unused for longer than this duration.
+	MaxIdleTime time.Duration
+	// MaxLifetime closes connections older than this duration.
+	MaxLifetime time.Duration
+}
+
+type pooledConnection struct {
+	conn     *Connection
+	created  time.Time
+	lastUsed time.Time
+}
+
+// Pool manages one writer and several reader connections on a database file
+// in WAL mode. Each connection is only handed to one goroutine at a time, so
+// a Pool can be shared between goroutines.
+//
+// Connections are opened lazily, except the writer which is opened by NewPool
+// to create the file and switch it to WAL mode.
+type Pool struct {
+	filename string
+	opts     PoolOptions
+	writer   chan *pooledConnection
+	readers  chan *pooledConnection
+	done     chan struct{}
+	closing  sync.Once
+}
+
+func NewPool(filename string, opts PoolOptions) (*Pool, error) {
+	if opts.Readers < 0 {
+		return nil, errors.New("negative number of readers")
+	}
+	if opts.Readers == 0 {
+		opts.Readers = defaultPoolReaders
+	}
+
+	p := &Pool{
+		filename: filename,
+		opts:     opts,
+		writer:   make(chan *pooledConnection, 1),
+		readers:  make(chan *pooledConnection, opts.Readers),
+		done:     make(chan struct{}),
+	}
+
+	writer, err := p.open(false)
+	if err != nil {
+		return nil, err
+	}
+	p.writer <- writer
+	for range opts.Readers {
+		p.readers <- nil
+	}
+
+	if interval := p.reapInterval(); interval > 0 {
+		go p.reap(interval)
+	}
+	return p, nil
+}
+
+// Read runs fn with a reader connection. Reader connections are opened with
+// query_only set, so fn cannot modify the database.
+func (p *Pool) Read(ctx context.Context, fn func(conn *Connection) error) error {
+	return p.run(ctx, p.readers, true, fn)
+}
+
+// Write runs fn with the writer connection. Calls to Write are serialized.
+func (p *Pool) Write(ctx context.Context, fn func(conn *Connection) error) error {
+	return p.run(ctx, p.writer, false, fn)
+}
+
+// Close waits for all connections to be released and closes them.
+func (p *Pool) Close() error {
+	closed := false
+	p.closing.Do(func() {
+		close(p.done)
+		closed = true
+	})
+	if !closed {
+		return ErrPoolClosed
+	}
+
+	var errs []error
+	for _, slots := range []chan *pooledConnection{p.writer, p.readers} {
+		for range cap(slots) {
+			if pc := <-slots; pc != nil {
+				errs = append(errs, pc.conn.Close())
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (p *Pool) run(ctx context.Context, slots chan *pooledConnection, readOnly bool, fn func(conn *Connection) error) error {
+	var pc *pooledConnection
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-p.done:
+		return ErrPoolClosed
+	case pc = <-slots:
+	}
+
+	select {
+	case <-p.done:
+		slots <- pc
+		return ErrPoolClosed
+	default:
+	}
+
+	pc, err := p.prepare(pc, readOnly)
+	if err != nil {
+		slots <- nil
+		return err
+	}
+	defer func() {
+		pc.lastUsed = time.Now()
+		slots <- pc
+	}()
+
+	return fn(pc.conn)
+}
+
+// prepare replaces a missing, expired or unhealthy connection with a new one.
+func (p *Pool) prepare(pc *pooledConnection, readOnly bool) (*pooledConnection, error) {
+	if pc != nil && p.expired(pc, time.Now()) {
+		pc.conn.Close()
+		pc = nil
+	}
+	if pc != nil && p.opts.HealthCheck != nil {
+		if err := p.opts.HealthCheck(pc.conn); err != nil {
+			pc.conn.Close()
+			pc = nil
+		}
+	}
+	if pc == nil {
+		return p.open(readOnly)
+	}
+	return pc, nil
+}
+
+func (p *Pool) open(readOnly bool) (*pooledConnection, error) {
+	conn, err := Open(p.filename)
+	if err != nil {
+		return nil, err
+	}
+
+	if readOnly {
+		err = conn.Exec("PRAGMA query_only = ON")
+	} else {
+		err = conn.Exec("PRAGMA journal_mode = WAL")
+	}
+	if err == nil && p.opts.Init != nil {
+		err = p.opts.Init(conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	now := time.Now()
+	return &pooledConnection{conn: conn, created: now, lastUsed: now}, nil
+}
+
+func (p *Pool) expired(pc *pooledConnection, now time.Time) bool {
+	if p.opts.MaxLifetime > 0 && now.Sub(pc.created) > p.opts.MaxLifetime {
+		return true
+	}
+	if p.opts.MaxIdleTime > 0 && now.Sub(pc.lastUsed) > p.opts.MaxIdleTime {
+		return true
+	}
+	return false
+}
+
+func (p *Pool) reapInterval() time.Duration {
+	interval := p.opts.MaxIdleTime
+	if p.opts.MaxLifetime > 0 && (interval == 0 || p.opts.MaxLifetime < interval) {
+		interval = p.opts.MaxLifetime
+	}
+	return interval / 2
+}
+
+// reap periodically closes expired connections that are not in use.
+func (p *Pool) reap(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.reapSlots(p.writer)
+			p.reapSlots(p.readers)
+		}
+	}
+}
+
+func (p *Pool) reapSlots(slots chan *pooledConnection) {
+	now := time.Now()
+	for range cap(slots) {
+		var pc *pooledConnection
+		select {
+		case <-p.done:
+			return
+		case pc = <-slots:
+		default:
+			return
+		}
+		if pc != nil && p.expired(pc, now) {
+			pc.conn.Close()
+			pc = nil
+		}
+		slots <- pc
+	}
+}
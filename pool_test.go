@@ -0,0 +1,140 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPoolReadWrite(t *testing.T) {
+	var initialized atomic.Int32
+	pool, err := goliat.NewPool(filepath.Join(t.TempDir(), "test.db"), goliat.PoolOptions{
+		Readers: 2,
+		Init: func(conn *goliat.Connection) error {
+			initialized.Add(1)
+			return conn.Exec("PRAGMA busy_timeout = 1000")
+		},
+	})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	err = pool.Write(ctx, func(conn *goliat.Connection) error {
+		var mode string
+		if err := conn.QueryRow("PRAGMA journal_mode").Scan(&mode); err != nil {
+			return err
+		}
+		assert.Equal(t, "wal", mode)
+		if err := conn.Exec("CREATE TABLE foo (bar INTEGER)"); err != nil {
+			return err
+		}
+		return conn.Exec("INSERT INTO foo (bar) VALUES (?)", 42)
+	})
+	assert.NoError(t, err)
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := pool.Read(ctx, func(conn *goliat.Connection) error {
+				var bar int
+				if err := conn.QueryRow("SELECT bar FROM foo").Scan(&bar); err != nil {
+					return err
+				}
+				assert.Equal(t, 42, bar)
+				return nil
+			})
+			assert.NoError(t, err)
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(3), initialized.Load())
+}
+
+func TestPoolReadersAreReadOnly(t *testing.T) {
+	pool, err := goliat.NewPool(filepath.Join(t.TempDir(), "test.db"), goliat.PoolOptions{Readers: 1})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	ctx := context.Background()
+	err = pool.Write(ctx, func(conn *goliat.Connection) error {
+		return conn.Exec("CREATE TABLE foo (bar INTEGER)")
+	})
+	assert.NoError(t, err)
+
+	err = pool.Read(ctx, func(conn *goliat.Connection) error {
+		return conn.Exec("INSERT INTO foo (bar) VALUES (1)")
+	})
+	assert.Error(t, err)
+}
+
+func TestPoolContextCancellation(t *testing.T) {
+	pool, err := goliat.NewPool(filepath.Join(t.TempDir(), "test.db"), goliat.PoolOptions{})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	err = pool.Write(context.Background(), func(conn *goliat.Connection) error {
+		return pool.Write(ctx, func(conn *goliat.Connection) error {
+			return nil
+		})
+	})
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestPoolMaxLifetime(t *testing.T) {
+	var initialized atomic.Int32
+	pool, err := goliat.NewPool(filepath.Join(t.TempDir(), "test.db"), goliat.PoolOptions{
+		Readers:     1,
+		MaxLifetime: time.Millisecond,
+		Init: func(conn *goliat.Connection) error {
+			initialized.Add(1)
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+	defer pool.Close()
+
+	time.Sleep(5 * time.Millisecond)
+	err = pool.Write(context.Background(), func(conn *goliat.Connection) error {
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), initialized.Load())
+}
+
+func TestPoolClose(t *testing.T) {
+	pool, err := goliat.NewPool(filepath.Join(t.TempDir(), "test.db"), goliat.PoolOptions{})
+	assert.NoError(t, err)
+	assert.NoError(t, pool.Close())
+
+	err = pool.Read(context.Background(), func(conn *goliat.Connection) error {
+		return nil
+	})
+	assert.ErrorIs(t, err, goliat.ErrPoolClosed)
+	assert.ErrorIs(t, pool.Close(), goliat.ErrPoolClosed)
+}
@@ -0,0 +1,308 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+type JournalMode string
+
+const (
+	JournalModeDelete   = JournalMode("delete")
+	JournalModeTruncate = JournalMode("truncate")
+	JournalModePersist  = JournalMode("persist")
+	JournalModeMemory   = JournalMode("memory")
+	JournalModeWAL      = JournalMode("wal")
+	JournalModeOff      = JournalMode("off")
+)
+
+type Synchronous int
+
+const (
+	SynchronousOff    = Synchronous(0)
+	SynchronousNormal = Synchronous(1)
+	SynchronousFull   = Synchronous(2)
+	SynchronousExtra  = Synchronous(3)
+)
+
+type AutoVacuum int
+
+const (
+	AutoVacuumNone        = AutoVacuum(0)
+	AutoVacuumFull        = AutoVacuum(1)
+	AutoVacuumIncremental = AutoVacuum(2)
+)
+
+type TempStore int
+
+const (
+	TempStoreDefault = TempStore(0)
+	TempStoreFile    = TempStore(1)
+	TempStoreMemory  = TempStore(2)
+)
+
+// Pragmas gives typed access to the PRAGMA statements of a connection.
+// Pragmas that apply to a single database target the main database unless
+// another one is selected with Database.
+type Pragmas struct {
+	db           *Connection
+	databaseName DatabaseName
+}
+
+func (d *Connection) Pragmas() Pragmas {
+	return Pragmas{db: d, databaseName: DatabaseNameMain}
+}
+
+// Database returns a Pragmas targeting the given attached database.
+func (p Pragmas) Database(databaseName DatabaseName) Pragmas {
+	return Pragmas{db: p.db, databaseName: databaseName}
+}
+
+func (p Pragmas) qualified(name string) string {
+	return quoteIdentifier(string(p.databaseName)) + "." + name
+}
+
+func (p Pragmas) queryInt(pragma string) (int64, error) {
+	var value int64
+	err := p.db.QueryRow("PRAGMA " + pragma).Scan(&value)
+	return value, err
+}
+
+func (p Pragmas) queryText(pragma string) (string, error) {
+	var value string
+	err := p.db.QueryRow("PRAGMA " + pragma).Scan(&value)
+	return value, err
+}
+
+// JournalMode returns the journal mode, lowercased like the JournalMode
+// constants.
+func (p Pragmas) JournalMode() (JournalMode, error) {
+	mode, err := p.queryText(p.qualified("journal_mode"))
+	return JournalMode(strings.ToLower(mode)), err
+}
+
+// SetJournalMode changes the journal mode and returns the resulting one, which
+// can differ from mode when the change is not possible, for example WAL on an
+// in-memory database. Modes are matched case-insensitively and returned
+// lowercased like the JournalMode constants.
+func (p Pragmas) SetJournalMode(mode JournalMode) (JournalMode, error) {
+	mode = JournalMode(strings.ToLower(string(mode)))
+	switch mode {
+	case JournalModeDelete, JournalModeTruncate, JournalModePersist, JournalModeMemory, JournalModeWAL, JournalModeOff:
+	default:
+		return "", fmt.Errorf("invalid journal mode %q", mode)
+	}
+	result, err := p.queryText(fmt.Sprintf("%s = %s", p.qualified("journal_mode"), mode))
+	return JournalMode(strings.ToLower(result)), err
+}
+
+func (p Pragmas) Synchronous() (Synchronous, error) {
+	value, err := p.queryInt(p.qualified("synchronous"))
+	return Synchronous(value), err
+}
+
+func (p Pragmas) SetSynchronous(value Synchronous) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("synchronous"), int(value)))
+}
+
+func (p Pragmas) ForeignKeys() (bool, error) {
+	value, err := p.queryInt("foreign_keys")
+	return value != 0, err
+}
+
+// SetForeignKeys enables or disables foreign key enforcement. It has no
+// effect inside a transaction.
+func (p Pragmas) SetForeignKeys(enable bool) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA foreign_keys = %d", boolToInt(enable)))
+}
+
+// CacheSize returns the page cache size. Positive values are pages, negative
+// values are KiB.
+func (p Pragmas) CacheSize() (int, error) {
+	value, err := p.queryInt(p.qualified("cache_size"))
+	return int(value), err
+}
+
+func (p Pragmas) SetCacheSize(size int) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("cache_size"), size))
+}
+
+func (p Pragmas) MmapSize() (int64, error) {
+	return p.queryInt(p.qualified("mmap_size"))
+}
+
+func (p Pragmas) SetMmapSize(size int64) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("mmap_size"), size))
+}
+
+func (p Pragmas) BusyTimeout() (time.Duration, error) {
+	value, err := p.queryInt("busy_timeout")
+	return time.Duration(value) * time.Millisecond, err
+}
+
+func (p Pragmas) SetBusyTimeout(timeout time.Duration) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA busy_timeout = %d", timeout.Milliseconds()))
+}
+
+func (p Pragmas) UserVersion() (int, error) {
+	value, err := p.queryInt(p.qualified("user_version"))
+	return int(value), err
+}
+
+func (p Pragmas) SetUserVersion(version int) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("user_version"), version))
+}
+
+func (p Pragmas) ApplicationID() (int32, error) {
+	value, err := p.queryInt(p.qualified("application_id"))
+	return int32(value), err
+}
+
+func (p Pragmas) SetApplicationID(id int32) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("application_id"), id))
+}
+
+func (p Pragmas) AutoVacuum() (AutoVacuum, error) {
+	value, err := p.queryInt(p.qualified("auto_vacuum"))
+	return AutoVacuum(value), err
+}
+
+// SetAutoVacuum changes the auto-vacuum mode. Switching an existing database
+// between none and the other modes only takes effect after a VACUUM.
+func (p Pragmas) SetAutoVacuum(mode AutoVacuum) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA %s = %d", p.qualified("auto_vacuum"), int(mode)))
+}
+
+func (p Pragmas) TempStore() (TempStore, error) {
+	value, err := p.queryInt("temp_store")
+	return TempStore(value), err
+}
+
+func (p Pragmas) SetTempStore(store TempStore) error {
+	return p.db.Exec(fmt.Sprintf("PRAGMA temp_store = %d", int(store)))
+}
+
+// nullString scans a nullable text column.
+type nullString struct {
+	value *string
+}
+
+func (n *nullString) FromSQLiteValue(value ColumnValue) error {
+	if value.IsNull() {
+		n.value = nil
+		return nil
+	}
+	text := value.stmt.columnText(value.index)
+	n.value = &text
+	return nil
+}
+
+// ColumnInfo is a row of PRAGMA table_info.
+type ColumnInfo struct {
+	ID      int
+	Name    string
+	Type    string
+	NotNull bool
+	// DefaultValue is the SQL text of the default value, nil if there is none.
+	DefaultValue *string
+	// PrimaryKey is the 1-based position of the column in the primary key,
+	// or 0 if the column is not part of it.
+	PrimaryKey int
+}
+
+func (p Pragmas) TableInfo(table string) ([]ColumnInfo, error) {
+	rows, err := p.db.Query(`SELECT cid, name, type, "notnull", dflt_value, pk FROM pragma_table_info(?, ?)`, table, string(p.databaseName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ColumnInfo
+	for rows.Next() {
+		var column ColumnInfo
+		var defaultValue nullString
+		if err := rows.Scan(&column.ID, &column.Name, &column.Type, &column.NotNull, &defaultValue, &column.PrimaryKey); err != nil {
+			return nil, err
+		}
+		column.DefaultValue = defaultValue.value
+		result = append(result, column)
+	}
+	return result, rows.Err()
+}
+
+// IndexInfo is a row of PRAGMA index_list.
+type IndexInfo struct {
+	Seq    int
+	Name   string
+	Unique bool
+	// Origin is "c" for CREATE INDEX, "u" for UNIQUE constraints and "pk"
+	// for PRIMARY KEY constraints.
+	Origin  string
+	Partial bool
+}
+
+func (p Pragmas) IndexList(table string) ([]IndexInfo, error) {
+	rows, err := p.db.Query(`SELECT seq, name, "unique", origin, partial FROM pragma_index_list(?, ?)`, table, string(p.databaseName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []IndexInfo
+	for rows.Next() {
+		var index IndexInfo
+		if err := rows.Scan(&index.Seq, &index.Name, &index.Unique, &index.Origin, &index.Partial); err != nil {
+			return nil, err
+		}
+		result = append(result, index)
+	}
+	return result, rows.Err()
+}
+
+// ForeignKeyInfo is a row of PRAGMA foreign_key_list. Composite foreign keys
+// produce one row per column sharing the same ID.
+type ForeignKeyInfo struct {
+	ID    int
+	Seq   int
+	Table string
+	From  string
+	// To is the referenced column, empty when the key references the
+	// primary key implicitly.
+	To       string
+	OnUpdate string
+	OnDelete string
+	Match    string
+}
+
+func (p Pragmas) ForeignKeyList(table string) ([]ForeignKeyInfo, error) {
+	rows, err := p.db.Query(`SELECT id, seq, "table", "from", "to", on_update, on_delete, "match" FROM pragma_foreign_key_list(?, ?)`, table, string(p.databaseName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var result []ForeignKeyInfo
+	for rows.Next() {
+		var key ForeignKeyInfo
+		if err := rows.Scan(&key.ID, &key.Seq, &key.Table, &key.From, &key.To, &key.OnUpdate, &key.OnDelete, &key.Match); err != nil {
+			return nil, err
+		}
+		result = append(result, key)
+	}
+	return result, rows.Err()
+}
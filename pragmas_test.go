@@ -0,0 +1,150 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPragmas(t *testing.T) {
+	db, err := goliat.Open(filepath.Join(t.TempDir(), "test.db"))
+	assert.NoError(t, err)
+	defer db.Close()
+
+	pragmas := db.Pragmas()
+
+	mode, err := pragmas.SetJournalMode(goliat.JournalModeWAL)
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.JournalModeWAL, mode)
+	mode, err = pragmas.JournalMode()
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.JournalModeWAL, mode)
+	mode, err = pragmas.SetJournalMode("DELETE")
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.JournalModeDelete, mode)
+	mode, err = pragmas.JournalMode()
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.JournalModeDelete, mode)
+	_, err = pragmas.SetJournalMode("wal; DROP TABLE foo")
+	assert.Error(t, err)
+
+	assert.NoError(t, pragmas.SetSynchronous(goliat.SynchronousNormal))
+	synchronous, err := pragmas.Synchronous()
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.SynchronousNormal, synchronous)
+
+	assert.NoError(t, pragmas.SetForeignKeys(true))
+	foreignKeys, err := pragmas.ForeignKeys()
+	assert.NoError(t, err)
+	assert.True(t, foreignKeys)
+
+	assert.NoError(t, pragmas.SetCacheSize(-4096))
+	cacheSize, err := pragmas.CacheSize()
+	assert.NoError(t, err)
+	assert.Equal(t, -4096, cacheSize)
+
+	assert.NoError(t, pragmas.SetMmapSize(1<<20))
+	_, err = pragmas.MmapSize()
+	assert.NoError(t, err)
+
+	assert.NoError(t, pragmas.SetBusyTimeout(2*time.Second))
+	busyTimeout, err := pragmas.BusyTimeout()
+	assert.NoError(t, err)
+	assert.Equal(t, 2*time.Second, busyTimeout)
+
+	assert.NoError(t, pragmas.SetUserVersion(7))
+	userVersion, err := pragmas.UserVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 7, userVersion)
+
+	assert.NoError(t, pragmas.SetApplicationID(0x676f6c69))
+	applicationID, err := pragmas.ApplicationID()
+	assert.NoError(t, err)
+	assert.Equal(t, int32(0x676f6c69), applicationID)
+
+	assert.NoError(t, pragmas.SetAutoVacuum(goliat.AutoVacuumIncremental))
+	assert.NoError(t, db.Exec("VACUUM"))
+	autoVacuum, err := pragmas.AutoVacuum()
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.AutoVacuumIncremental, autoVacuum)
+
+	assert.NoError(t, pragmas.SetTempStore(goliat.TempStoreMemory))
+	tempStore, err := pragmas.TempStore()
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.TempStoreMemory, tempStore)
+}
+
+func TestPragmasDatabase(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	assert.NoError(t, db.Exec("ATTACH ':memory:' AS other"))
+	assert.NoError(t, db.Pragmas().Database("other").SetUserVersion(3))
+
+	userVersion, err := db.Pragmas().UserVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, userVersion)
+	userVersion, err = db.Pragmas().Database("other").UserVersion()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, userVersion)
+}
+
+func TestPragmasTableInfo(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE parent (id INTEGER PRIMARY KEY, name TEXT NOT NULL DEFAULT 'foo' UNIQUE)")
+	assert.NoError(t, err)
+	err = db.Exec("CREATE TABLE child (id INTEGER PRIMARY KEY, parent INTEGER REFERENCES parent (id) ON DELETE CASCADE)")
+	assert.NoError(t, err)
+	err = db.Exec("CREATE INDEX child_parent ON child (parent) WHERE parent IS NOT NULL")
+	assert.NoError(t, err)
+
+	columns, err := db.Pragmas().TableInfo("parent")
+	assert.NoError(t, err)
+	defaultName := "'foo'"
+	assert.Equal(t, []goliat.ColumnInfo{
+		{ID: 0, Name: "id", Type: "INTEGER", PrimaryKey: 1},
+		{ID: 1, Name: "name", Type: "TEXT", NotNull: true, DefaultValue: &defaultName},
+	}, columns)
+
+	indexes, err := db.Pragmas().IndexList("parent")
+	assert.NoError(t, err)
+	assert.Len(t, indexes, 1)
+	assert.True(t, indexes[0].Unique)
+	assert.Equal(t, "u", indexes[0].Origin)
+
+	indexes, err = db.Pragmas().IndexList("child")
+	assert.NoError(t, err)
+	assert.Equal(t, []goliat.IndexInfo{{Seq: 0, Name: "child_parent", Origin: "c", Partial: true}}, indexes)
+
+	keys, err := db.Pragmas().ForeignKeyList("child")
+	assert.NoError(t, err)
+	assert.Equal(t, []goliat.ForeignKeyInfo{{
+		Table:    "parent",
+		From:     "parent",
+		To:       "id",
+		OnUpdate: "NO ACTION",
+		OnDelete: "CASCADE",
+		Match:    "NONE",
+	}}, keys)
+}
@@ -0,0 +1,67 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+#include <stdint.h>
+
+extern int goliatProgressCallback(void*);
+
+static inline void goliat_progress_handler(sqlite3 *db, int nOps, uintptr_t handle) {
+	sqlite3_progress_handler(db, nOps, handle ? goliatProgressCallback : 0, (void*)handle);
+}
+*/
+import "C"
+
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// SetProgressHandler registers fn to be called roughly every nOps virtual
+// machine instructions while a statement runs. If fn returns true the
+// statement is aborted and fails with INTERRUPT. Passing a nil fn or a
+// non-positive nOps removes the handler.
+func (d *Connection) SetProgressHandler(nOps int, fn func() bool) {
+	if nOps <= 0 || fn == nil {
+		C.goliat_progress_handler(d.h.ptr, 0, 0)
+		d.h.replaceCallback("progress", nil)
+		return
+	}
+	handle := d.h.replaceCallback("progress", fn)
+	C.goliat_progress_handler(d.h.ptr, C.int(nOps), handle)
+}
+
+// Interrupt aborts any statement running on the connection, which then fails
+// with INTERRUPT. Unlike the other methods it is safe to call from another
+// goroutine, even while or after the connection is closed, in which case it
+// does nothing.
+func (d *Connection) Interrupt() {
+	d.h.mu.Lock()
+	defer d.h.mu.Unlock()
+	if d.h.ptr != nil {
+		C.sqlite3_interrupt(d.h.ptr)
+	}
+}
+
+//export goliatProgressCallback
+func goliatProgressCallback(ctx unsafe.Pointer) C.int {
+	fn := cgo.Handle(uintptr(ctx)).Value().(func() bool)
+	if fn() {
+		return 1
+	}
+	return 0
+}
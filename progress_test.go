@@ -0,0 +1,94 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+const longRunningQuery = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 100000000) SELECT count(*) FROM c"
+
+func TestSetProgressHandler(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	db.SetProgressHandler(100, func() bool {
+		calls++
+		return false
+	})
+	var count int
+	err = db.QueryRow("WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x + 1 FROM c WHERE x < 1000) SELECT count(*) FROM c").Scan(&count)
+	assert.NoError(t, err)
+	assert.Equal(t, 1000, count)
+	assert.Greater(t, calls, 0)
+
+	db.SetProgressHandler(0, nil)
+	calls = 0
+	assert.NoError(t, db.Exec("SELECT 1"))
+	assert.Equal(t, 0, calls)
+}
+
+func TestSetProgressHandlerAbort(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	deadline := time.Now().Add(10 * time.Millisecond)
+	db.SetProgressHandler(1000, func() bool {
+		return time.Now().After(deadline)
+	})
+
+	err = db.Exec(longRunningQuery)
+	var dbErr *goliat.DatabaseError
+	assert.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, goliat.INTERRUPT, dbErr.Code)
+}
+
+func TestInterrupt(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	timer := time.AfterFunc(10*time.Millisecond, db.Interrupt)
+	defer timer.Stop()
+
+	err = db.Exec(longRunningQuery)
+	var dbErr *goliat.DatabaseError
+	assert.True(t, errors.As(err, &dbErr))
+	assert.Equal(t, goliat.INTERRUPT, dbErr.Code)
+}
+
+func TestInterruptAfterClose(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range 1000 {
+			db.Interrupt()
+		}
+	}()
+	assert.NoError(t, db.Close())
+	<-done
+	db.Interrupt()
+}
@@ -0,0 +1,204 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+)
+
+// readerVFSName is the name under which readerVFS is registered the first
+// time OpenReaderAt or OpenFS is called.
+const readerVFSName = "goliat-reader"
+
+// readerVFS serves database files backed by an io.ReaderAt. Each database is
+// added under a unique name before opening it and removed when SQLite opens
+// it, so the connection owns the reader from then on. Any other file, such
+// as those used for sorting, is kept in memory.
+type readerVFS struct {
+	mu      sync.Mutex
+	readers map[string]*readerFile
+	opened  int
+	temp    VFS
+}
+
+var readerVFSInstance = sync.OnceValues(func() (*readerVFS, error) {
+	vfs := &readerVFS{readers: make(map[string]*readerFile), temp: NewMemoryVFS()}
+	if err := RegisterVFS(readerVFSName, vfs, false); err != nil {
+		return nil, err
+	}
+	return vfs, nil
+})
+
+func (v *readerVFS) add(file *readerFile) string {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.opened++
+	name := fmt.Sprintf("/reader-%d.db", v.opened)
+	v.readers[name] = file
+	return name
+}
+
+// remove removes name if SQLite did not open it, reporting whether it did so.
+func (v *readerVFS) remove(name string) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	_, ok := v.readers[name]
+	delete(v.readers, name)
+	return ok
+}
+
+func (v *readerVFS) Open(name string, flags OpenFlag) (File, OpenFlag, error) {
+	if flags&OpenFlagMainDB == 0 {
+		return v.temp.Open(name, flags)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	file, ok := v.readers[name]
+	if !ok {
+		return nil, 0, newDatabaseError(CANTOPEN, fmt.Sprintf("file %q does not exist", name))
+	}
+	delete(v.readers, name)
+	return file, OpenFlagReadOnly | OpenFlagMainDB, nil
+}
+
+func (v *readerVFS) Delete(name string, syncDir bool) error {
+	return v.temp.Delete(name, syncDir)
+}
+
+func (v *readerVFS) Access(name string, flags AccessFlag) (bool, error) {
+	return v.temp.Access(name, flags)
+}
+
+func (v *readerVFS) FullPathname(name string) (string, error) {
+	return name, nil
+}
+
+type readerFile struct {
+	r      io.ReaderAt
+	size   int64
+	closer io.Closer
+}
+
+func (f *readerFile) Close() error {
+	if f.closer != nil {
+		return f.closer.Close()
+	}
+	return nil
+}
+
+func (f *readerFile) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	if remaining := f.size - off; int64(len(p)) > remaining {
+		n, err := f.r.ReadAt(p[:remaining], off)
+		if err == nil {
+			err = io.EOF
+		}
+		return n, err
+	}
+	return f.r.ReadAt(p, off)
+}
+
+func (f *readerFile) WriteAt(p []byte, off int64) (int, error) {
+	return 0, newDatabaseError(READONLY, "database is read-only")
+}
+
+func (f *readerFile) Truncate(size int64) error {
+	return newDatabaseError(READONLY, "database is read-only")
+}
+
+func (f *readerFile) Sync(flags SyncFlag) error {
+	return nil
+}
+
+func (f *readerFile) FileSize() (int64, error) {
+	return f.size, nil
+}
+
+func (f *readerFile) Lock(level LockLevel) error {
+	return nil
+}
+
+func (f *readerFile) Unlock(level LockLevel) error {
+	return nil
+}
+
+func (f *readerFile) CheckReservedLock() (bool, error) {
+	return false, nil
+}
+
+func (f *readerFile) SectorSize() int {
+	return 4096
+}
+
+func (f *readerFile) DeviceCharacteristics() DeviceCharacteristic {
+	return DeviceCharacteristicImmutable
+}
+
+// OpenReaderAt opens a read-only connection to the database image made of the
+// first size bytes of r, for example a database embedded in the binary or
+// stored in an archive, without copying it to disk. The content of r must
+// not change while the connection is open.
+func OpenReaderAt(r io.ReaderAt, size int64) (*Connection, error) {
+	return openReaderFile(&readerFile{r: r, size: size})
+}
+
+// OpenFS opens a read-only connection to the database file name of fsys, such
+// as an embed.FS. The file is read in place when it implements io.ReaderAt
+// and loaded in memory otherwise. It is closed with the connection.
+func OpenFS(fsys fs.FS, name string) (*Connection, error) {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if r, ok := f.(io.ReaderAt); ok {
+		return openReaderFile(&readerFile{r: r, size: info.Size(), closer: f})
+	}
+	data, err := io.ReadAll(f)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+	return openReaderFile(&readerFile{r: bytes.NewReader(data), size: int64(len(data))})
+}
+
+func openReaderFile(file *readerFile) (*Connection, error) {
+	vfs, err := readerVFSInstance()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	name := vfs.add(file)
+	// immutable=1 tells SQLite that nobody can change the database, so it
+	// neither locks it nor looks for a journal.
+	d, err := OpenWithOptions("file:"+name+"?immutable=1", OpenOptions{VFS: readerVFSName, ReadOnly: true})
+	if err != nil {
+		if vfs.remove(name) {
+			file.Close()
+		}
+		return nil, err
+	}
+	return d, nil
+}
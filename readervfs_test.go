@@ -0,0 +1,79 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func createLookupDatabase(t *testing.T) (dir string, data []byte) {
+	dir = t.TempDir()
+	path := filepath.Join(dir, "lookup.db")
+	db, err := goliat.Open(path)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT)"))
+	assert.NoError(t, db.Exec("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 500) INSERT INTO foo SELECT i, 'row' || i FROM n"))
+	assert.NoError(t, db.Close())
+	data, err = os.ReadFile(path)
+	assert.NoError(t, err)
+	return dir, data
+}
+
+func assertLookupDatabase(t *testing.T, db *goliat.Connection) {
+	var bar string
+	assert.NoError(t, db.QueryRow("SELECT bar FROM foo WHERE id = ?", 250).Scan(&bar))
+	assert.Equal(t, "row250", bar)
+	assert.NoError(t, db.QueryRow("SELECT group_concat(bar) FROM (SELECT bar FROM foo ORDER BY bar DESC LIMIT 2)").Scan(&bar))
+	assert.Equal(t, "row99,row98", bar)
+	assert.Error(t, db.Exec("INSERT INTO foo (bar) VALUES ('baz')"))
+}
+
+func TestOpenReaderAt(t *testing.T) {
+	_, data := createLookupDatabase(t)
+
+	db, err := goliat.OpenReaderAt(bytes.NewReader(data), int64(len(data)))
+	assert.NoError(t, err)
+	assertLookupDatabase(t, db)
+	assert.NoError(t, db.Close())
+
+	db, err = goliat.OpenReaderAt(bytes.NewReader([]byte("not a database")), 14)
+	assert.NoError(t, err)
+	assert.Error(t, db.Exec("SELECT * FROM sqlite_schema"))
+	assert.NoError(t, db.Close())
+}
+
+func TestOpenFS(t *testing.T) {
+	dir, data := createLookupDatabase(t)
+
+	db, err := goliat.OpenFS(os.DirFS(dir), "lookup.db")
+	assert.NoError(t, err)
+	assertLookupDatabase(t, db)
+	assert.NoError(t, db.Close())
+
+	db, err = goliat.OpenFS(fstest.MapFS{"data/lookup.db": {Data: data}}, "data/lookup.db")
+	assert.NoError(t, err)
+	assertLookupDatabase(t, db)
+	assert.NoError(t, db.Close())
+
+	_, err = goliat.OpenFS(os.DirFS(dir), "missing.db")
+	assert.Error(t, err)
+}
@@ -0,0 +1,275 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#cgo CFLAGS: -DSQLITE_ENABLE_COLUMN_METADATA
+#include "sqlite3.h"
+*/
+import "C"
+
+import "fmt"
+
+// ColumnMetadata is the result of sqlite3_table_column_metadata.
+type ColumnMetadata struct {
+	DataType          string
+	CollationSequence string
+	NotNull           bool
+	PrimaryKey        bool
+	AutoIncrement     bool
+}
+
+// TableColumnMetadata returns the declared metadata of a table column. An
+// empty databaseName searches all attached databases.
+func (d *Connection) TableColumnMetadata(databaseName DatabaseName, tableName string, columnName string) (ColumnMetadata, error) {
+	var databaseNameRaw *C.char
+	if databaseName != "" {
+		s := newDatabaseString(string(databaseName))
+		defer s.Close()
+		databaseNameRaw = s.h.ptr
+	}
+	tableNameRaw := newDatabaseString(tableName)
+	defer tableNameRaw.Close()
+	columnNameRaw := newDatabaseString(columnName)
+	defer columnNameRaw.Close()
+
+	var dataType, collationSequence *C.char
+	var notNull, primaryKey, autoIncrement C.int
+	ec := C.sqlite3_table_column_metadata(d.h.ptr, databaseNameRaw, tableNameRaw.h.ptr, columnNameRaw.h.ptr,
+		&dataType, &collationSequence, &notNull, &primaryKey, &autoIncrement)
+	if ec != C.SQLITE_OK {
+		return ColumnMetadata{}, d.newDatabaseError()
+	}
+	return ColumnMetadata{
+		DataType:          C.GoString(dataType),
+		CollationSequence: C.GoString(collationSequence),
+		NotNull:           notNull != 0,
+		PrimaryKey:        primaryKey != 0,
+		AutoIncrement:     autoIncrement != 0,
+	}, nil
+}
+
+type Column struct {
+	ColumnInfo
+	CollationSequence string
+	AutoIncrement     bool
+}
+
+type Index struct {
+	IndexInfo
+	Table string
+	// Columns lists the indexed column names in order. Expressions are
+	// reported as empty strings.
+	Columns []string
+	// SQL is the CREATE INDEX statement, empty for indexes created
+	// implicitly by UNIQUE and PRIMARY KEY constraints.
+	SQL string
+}
+
+// ForeignKey groups the rows of PRAGMA foreign_key_list sharing the same ID.
+type ForeignKey struct {
+	ID       int
+	Table    string
+	From     []string
+	To       []string
+	OnUpdate string
+	OnDelete string
+	Match    string
+}
+
+type Table struct {
+	Name string
+	// Type is "table" for ordinary tables and "virtual" for virtual tables.
+	Type         string
+	WithoutRowID bool
+	Strict       bool
+	SQL          string
+	Columns      []Column
+	Indexes      []Index
+	ForeignKeys  []ForeignKey
+}
+
+type View struct {
+	Name    string
+	SQL     string
+	Columns []ColumnInfo
+}
+
+type Trigger struct {
+	Name  string
+	Table string
+	SQL   string
+}
+
+// Schema describes the objects stored in a database. Internal sqlite_ objects
+// are not included.
+type Schema struct {
+	Tables   []Table
+	Views    []View
+	Triggers []Trigger
+	// ForeignKeys reports whether the connection the schema was read from
+	// enforces foreign keys.
+	ForeignKeys bool
+}
+
+// Table returns the table with the given name, or nil.
+func (s *Schema) Table(name string) *Table {
+	for i := range s.Tables {
+		if s.Tables[i].Name == name {
+			return &s.Tables[i]
+		}
+	}
+	return nil
+}
+
+// Schema describes the main database.
+func (d *Connection) Schema() (*Schema, error) {
+	return d.DatabaseSchema(DatabaseNameMain)
+}
+
+// DatabaseSchema describes the given attached database.
+func (d *Connection) DatabaseSchema(databaseName DatabaseName) (*Schema, error) {
+	rows, err := d.Query(fmt.Sprintf(`SELECT type, name, tbl_name, sql FROM %s.sqlite_schema
+		WHERE substr(name, 1, 7) <> 'sqlite_' AND type IN ('table', 'view', 'trigger', 'index')
+		ORDER BY name`, quoteIdentifier(string(databaseName))))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	schema := &Schema{}
+	indexSQL := make(map[string]string)
+	for rows.Next() {
+		var kind, name, tableName string
+		var sql nullString
+		if err := rows.Scan(&kind, &name, &tableName, &sql); err != nil {
+			return nil, err
+		}
+		text := ""
+		if sql.value != nil {
+			text = *sql.value
+		}
+		switch kind {
+		case "table":
+			schema.Tables = append(schema.Tables, Table{Name: name, SQL: text})
+		case "view":
+			schema.Views = append(schema.Views, View{Name: name, SQL: text})
+		case "trigger":
+			schema.Triggers = append(schema.Triggers, Trigger{Name: name, Table: tableName, SQL: text})
+		case "index":
+			indexSQL[name] = text
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	pragmas := d.Pragmas().Database(databaseName)
+	if schema.ForeignKeys, err = pragmas.ForeignKeys(); err != nil {
+		return nil, err
+	}
+	for i := range schema.Tables {
+		if err := d.describeTable(pragmas, &schema.Tables[i], indexSQL); err != nil {
+			return nil, err
+		}
+	}
+	for i := range schema.Views {
+		columns, err := pragmas.TableInfo(schema.Views[i].Name)
+		if err != nil {
+			return nil, err
+		}
+		schema.Views[i].Columns = columns
+	}
+	return schema, nil
+}
+
+func (d *Connection) describeTable(pragmas Pragmas, table *Table, indexSQL map[string]string) error {
+	err := d.QueryRow("SELECT type, wr, strict FROM pragma_table_list WHERE name = ? AND schema = ?", table.Name, string(pragmas.databaseName)).
+		Scan(&table.Type, &table.WithoutRowID, &table.Strict)
+	if err != nil {
+		return err
+	}
+
+	columns, err := pragmas.TableInfo(table.Name)
+	if err != nil {
+		return err
+	}
+	for _, info := range columns {
+		column := Column{ColumnInfo: info}
+		if table.Type == "table" {
+			metadata, err := d.TableColumnMetadata(pragmas.databaseName, table.Name, info.Name)
+			if err != nil {
+				return err
+			}
+			column.CollationSequence = metadata.CollationSequence
+			column.AutoIncrement = metadata.AutoIncrement
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	indexes, err := pragmas.IndexList(table.Name)
+	if err != nil {
+		return err
+	}
+	for _, info := range indexes {
+		index := Index{IndexInfo: info, Table: table.Name, SQL: indexSQL[info.Name]}
+		if index.Columns, err = d.indexColumns(pragmas, info.Name); err != nil {
+			return err
+		}
+		table.Indexes = append(table.Indexes, index)
+	}
+
+	keys, err := pragmas.ForeignKeyList(table.Name)
+	if err != nil {
+		return err
+	}
+	for _, info := range keys {
+		if n := len(table.ForeignKeys); n == 0 || table.ForeignKeys[n-1].ID != info.ID {
+			table.ForeignKeys = append(table.ForeignKeys, ForeignKey{
+				ID:       info.ID,
+				Table:    info.Table,
+				OnUpdate: info.OnUpdate,
+				OnDelete: info.OnDelete,
+				Match:    info.Match,
+			})
+		}
+		key := &table.ForeignKeys[len(table.ForeignKeys)-1]
+		key.From = append(key.From, info.From)
+		key.To = append(key.To, info.To)
+	}
+	return nil
+}
+
+func (d *Connection) indexColumns(pragmas Pragmas, index string) ([]string, error) {
+	rows, err := d.Query("SELECT name FROM pragma_index_info(?, ?) ORDER BY seqno", index, string(pragmas.databaseName))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var columns []string
+	for rows.Next() {
+		var name nullString
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		if name.value != nil {
+			columns = append(columns, *name.value)
+		} else {
+			columns = append(columns, "")
+		}
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,131 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTableColumnMetadata(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.Exec("CREATE TABLE foo (id INTEGER PRIMARY KEY AUTOINCREMENT, bar TEXT NOT NULL COLLATE NOCASE)")
+	assert.NoError(t, err)
+
+	metadata, err := db.TableColumnMetadata(goliat.DatabaseNameMain, "foo", "id")
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.ColumnMetadata{DataType: "INTEGER", CollationSequence: "BINARY", PrimaryKey: true, AutoIncrement: true}, metadata)
+
+	metadata, err = db.TableColumnMetadata("", "foo", "bar")
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.ColumnMetadata{DataType: "TEXT", CollationSequence: "NOCASE", NotNull: true}, metadata)
+
+	_, err = db.TableColumnMetadata(goliat.DatabaseNameMain, "foo", "missing")
+	assert.Error(t, err)
+}
+
+func TestSchema(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.ExecScript(`
+		CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, email TEXT NOT NULL UNIQUE COLLATE NOCASE);
+		CREATE TABLE posts (
+			id INTEGER PRIMARY KEY,
+			user_id INTEGER NOT NULL,
+			user_email TEXT,
+			title TEXT DEFAULT 'untitled',
+			FOREIGN KEY (user_id, user_email) REFERENCES users (id, email) ON DELETE CASCADE
+		) STRICT;
+		CREATE INDEX posts_title ON posts (title, lower(title));
+		CREATE VIEW user_posts AS SELECT users.email, posts.title FROM users JOIN posts ON posts.user_id = users.id;
+		CREATE TRIGGER posts_insert AFTER INSERT ON posts BEGIN SELECT 1; END;
+	`)
+	assert.NoError(t, err)
+
+	schema, err := db.Schema()
+	assert.NoError(t, err)
+
+	assert.Len(t, schema.Tables, 2)
+	users := schema.Table("users")
+	if assert.NotNil(t, users) {
+		assert.Equal(t, "table", users.Type)
+		assert.False(t, users.Strict)
+		assert.Len(t, users.Columns, 2)
+		assert.True(t, users.Columns[0].AutoIncrement)
+		assert.Equal(t, "NOCASE", users.Columns[1].CollationSequence)
+		assert.Len(t, users.Indexes, 1)
+		assert.Equal(t, "u", users.Indexes[0].Origin)
+		assert.Equal(t, []string{"email"}, users.Indexes[0].Columns)
+		assert.Empty(t, users.Indexes[0].SQL)
+	}
+
+	posts := schema.Table("posts")
+	if assert.NotNil(t, posts) {
+		assert.True(t, posts.Strict)
+		assert.Equal(t, "'untitled'", *posts.Columns[3].DefaultValue)
+		assert.Len(t, posts.Indexes, 1)
+		assert.Equal(t, "posts_title", posts.Indexes[0].Name)
+		assert.Equal(t, []string{"title", ""}, posts.Indexes[0].Columns)
+		assert.Contains(t, posts.Indexes[0].SQL, "CREATE INDEX posts_title")
+		assert.Equal(t, []goliat.ForeignKey{{
+			Table:    "users",
+			From:     []string{"user_id", "user_email"},
+			To:       []string{"id", "email"},
+			OnUpdate: "NO ACTION",
+			OnDelete: "CASCADE",
+			Match:    "NONE",
+		}}, posts.ForeignKeys)
+	}
+
+	assert.Nil(t, schema.Table("sqlite_sequence"))
+
+	assert.Len(t, schema.Views, 1)
+	assert.Equal(t, "user_posts", schema.Views[0].Name)
+	assert.Len(t, schema.Views[0].Columns, 2)
+
+	assert.Len(t, schema.Triggers, 1)
+	assert.Equal(t, "posts_insert", schema.Triggers[0].Name)
+	assert.Equal(t, "posts", schema.Triggers[0].Table)
+}
+
+func TestSchemaSqliteLikeNames(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	err = db.ExecScript(`
+		CREATE TABLE sqlitestats (id INTEGER PRIMARY KEY AUTOINCREMENT);
+		CREATE TABLE SQLiteUsers (id INTEGER PRIMARY KEY, email TEXT UNIQUE);
+		ANALYZE;
+	`)
+	assert.NoError(t, err)
+
+	schema, err := db.Schema()
+	assert.NoError(t, err)
+	names := make([]string, len(schema.Tables))
+	for i, table := range schema.Tables {
+		names[i] = table.Name
+	}
+	// Internal tables such as sqlite_sequence and sqlite_stat1 are left out.
+	assert.ElementsMatch(t, []string{"sqlitestats", "SQLiteUsers"}, names)
+	assert.Empty(t, schema.Table("SQLiteUsers").Indexes[0].SQL)
+}
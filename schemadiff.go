@@ -0,0 +1,452 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DiffSchema returns the SQL statements that turn the schema of the main
+// database of from into the schema of the main database of to. See
+// DiffSchemas.
+func DiffSchema(from, to *Connection) ([]string, error) {
+	fromSchema, err := from.Schema()
+	if err != nil {
+		return nil, err
+	}
+	toSchema, err := to.Schema()
+	if err != nil {
+		return nil, err
+	}
+	return DiffSchemas(fromSchema, toSchema)
+}
+
+// DiffSchemas returns the SQL statements that turn schema from into schema to,
+// or nil if they are equivalent. The statements run in a single transaction
+// and are meant to be executed in order, for example with ExecScript.
+//
+// Columns appended to a table are added with ALTER TABLE ADD COLUMN when
+// SQLite allows it. Any other table change uses the table rebuild procedure
+// recommended by SQLite: a new table is created, the columns present in both
+// definitions are copied, the old table is dropped and the new one renamed.
+// When tables are dropped or rebuilt all views and triggers are recreated.
+// If from enforces foreign keys, the statements also turn enforcement off
+// before the transaction and back on after it, and abort the transaction
+// when PRAGMA foreign_key_check reports a violation before committing. When
+// a statement fails the remaining ones must not run, and the caller is left
+// to roll back the transaction if still open and to turn enforcement back on.
+//
+// Tables are matched by name, so a renamed table or column is seen as a drop
+// followed by an add and its data is not preserved. Virtual tables cannot be
+// altered and produce an error.
+func DiffSchemas(from, to *Schema) ([]string, error) {
+	fromTables := tablesByName(from)
+	toTables := tablesByName(to)
+
+	var dropTables, createTables, alterTables []string
+	rebuilt := make(map[string]bool)
+	for _, table := range from.Tables {
+		if table.Type != "shadow" && toTables[table.Name] == nil {
+			dropTables = append(dropTables, "DROP TABLE "+quoteIdentifier(table.Name))
+		}
+	}
+	for _, table := range to.Tables {
+		if table.Type == "shadow" {
+			continue
+		}
+		old := fromTables[table.Name]
+		if old == nil {
+			createTables = append(createTables, table.SQL)
+			continue
+		}
+		if sameTableDefinition(old.SQL, table.SQL) {
+			continue
+		}
+		if old.Type == "virtual" || table.Type == "virtual" {
+			return nil, fmt.Errorf("cannot alter virtual table %s", table.Name)
+		}
+		if columns, ok := addedColumns(old.SQL, table.SQL); ok {
+			for _, column := range columns {
+				alterTables = append(alterTables, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", quoteIdentifier(table.Name), column))
+			}
+			continue
+		}
+		statements, err := rebuildTable(old, &table)
+		if err != nil {
+			return nil, err
+		}
+		alterTables = append(alterTables, statements...)
+		rebuilt[table.Name] = true
+	}
+
+	recreateAll := len(rebuilt) > 0 || len(dropTables) > 0
+	// Objects attached to dropped or rebuilt tables disappear with them.
+	gone := func(table string) bool {
+		return toTables[table] == nil || rebuilt[table]
+	}
+
+	var dropIndexes, createIndexes []string
+	fromIndexes := indexesByName(from)
+	toIndexes := indexesByName(to)
+	for _, index := range fromIndexes {
+		if gone(index.Table) {
+			continue
+		}
+		if other, ok := toIndexes[index.Name]; !ok || !sameSQL(index.SQL, other.SQL) {
+			dropIndexes = append(dropIndexes, "DROP INDEX IF EXISTS "+quoteIdentifier(index.Name))
+		}
+	}
+	for _, index := range sortedIndexes(toIndexes) {
+		other, ok := fromIndexes[index.Name]
+		if !ok || !sameSQL(index.SQL, other.SQL) || rebuilt[index.Table] || fromTables[index.Table] == nil {
+			createIndexes = append(createIndexes, index.SQL)
+		}
+	}
+
+	var dropViews, createViews []string
+	for _, view := range from.Views {
+		i := slices.IndexFunc(to.Views, func(other View) bool { return other.Name == view.Name })
+		if recreateAll || i < 0 || !sameSQL(view.SQL, to.Views[i].SQL) {
+			dropViews = append(dropViews, "DROP VIEW IF EXISTS "+quoteIdentifier(view.Name))
+		}
+	}
+	for _, view := range to.Views {
+		i := slices.IndexFunc(from.Views, func(other View) bool { return other.Name == view.Name })
+		if recreateAll || i < 0 || !sameSQL(view.SQL, from.Views[i].SQL) {
+			createViews = append(createViews, view.SQL)
+		}
+	}
+
+	var dropTriggers, createTriggers []string
+	for _, trigger := range from.Triggers {
+		i := slices.IndexFunc(to.Triggers, func(other Trigger) bool { return other.Name == trigger.Name })
+		if recreateAll || i < 0 || !sameSQL(trigger.SQL, to.Triggers[i].SQL) {
+			dropTriggers = append(dropTriggers, "DROP TRIGGER IF EXISTS "+quoteIdentifier(trigger.Name))
+		}
+	}
+	for _, trigger := range to.Triggers {
+		i := slices.IndexFunc(from.Triggers, func(other Trigger) bool { return other.Name == trigger.Name })
+		if recreateAll || i < 0 || !sameSQL(trigger.SQL, from.Triggers[i].SQL) {
+			createTriggers = append(createTriggers, trigger.SQL)
+		}
+	}
+
+	changes := slices.Concat(dropViews, dropTriggers, dropIndexes, dropTables,
+		createTables, alterTables, createIndexes, createViews, createTriggers)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	checkForeignKeys := recreateAll && from.ForeignKeys
+	var statements []string
+	if checkForeignKeys {
+		statements = append(statements, "PRAGMA foreign_keys = OFF")
+	}
+	statements = append(statements, "BEGIN")
+	statements = append(statements, changes...)
+	if checkForeignKeys {
+		statements = append(statements, foreignKeyCheck...)
+	}
+	statements = append(statements, "COMMIT")
+	if checkForeignKeys {
+		statements = append(statements, "PRAGMA foreign_keys = ON")
+	}
+	return statements, nil
+}
+
+// foreignKeyCheck rolls back the transaction if PRAGMA foreign_key_check
+// reports any violation. RAISE is only allowed in triggers, so the count of
+// violations goes through a temporary table whose trigger fails.
+var foreignKeyCheck = []string{
+	"CREATE TEMP TABLE goliat_foreign_key_check (violations INTEGER)",
+	"CREATE TEMP TRIGGER goliat_foreign_key_check AFTER INSERT ON goliat_foreign_key_check WHEN new.violations > 0 " +
+		"BEGIN SELECT RAISE(ROLLBACK, 'FOREIGN KEY constraint failed'); END",
+	"INSERT INTO goliat_foreign_key_check SELECT COUNT(*) FROM pragma_foreign_key_check",
+	"DROP TABLE goliat_foreign_key_check",
+}
+
+func tablesByName(schema *Schema) map[string]*Table {
+	result := make(map[string]*Table, len(schema.Tables))
+	for i := range schema.Tables {
+		result[schema.Tables[i].Name] = &schema.Tables[i]
+	}
+	return result
+}
+
+// indexesByName returns the explicitly created indexes of schema. Indexes
+// backing UNIQUE and PRIMARY KEY constraints are part of the table definition.
+func indexesByName(schema *Schema) map[string]Index {
+	result := make(map[string]Index)
+	for _, table := range schema.Tables {
+		for _, index := range table.Indexes {
+			if index.SQL != "" {
+				result[index.Name] = index
+			}
+		}
+	}
+	return result
+}
+
+func sortedIndexes(indexes map[string]Index) []Index {
+	result := make([]Index, 0, len(indexes))
+	for _, index := range indexes {
+		result = append(result, index)
+	}
+	slices.SortFunc(result, func(a, b Index) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return result
+}
+
+func rebuildTable(old *Table, table *Table) ([]string, error) {
+	tokens := tokenizeSQL(table.SQL)
+	open := slices.IndexFunc(tokens, func(token sqlToken) bool { return token.text == "(" })
+	if open < 0 {
+		return nil, fmt.Errorf("cannot parse definition of table %s", table.Name)
+	}
+
+	var columns []string
+	for _, column := range table.Columns {
+		if slices.ContainsFunc(old.Columns, func(other Column) bool { return other.Name == column.Name }) {
+			columns = append(columns, quoteIdentifier(column.Name))
+		}
+	}
+
+	name := quoteIdentifier(table.Name)
+	newName := quoteIdentifier("goliat_new_" + table.Name)
+	statements := []string{"CREATE TABLE " + newName + " " + table.SQL[tokens[open].start:]}
+	if len(columns) > 0 {
+		list := strings.Join(columns, ", ")
+		statements = append(statements, fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s", newName, list, list, name))
+	}
+	return append(statements,
+		"DROP TABLE "+name,
+		fmt.Sprintf("ALTER TABLE %s RENAME TO %s", newName, name),
+	), nil
+}
+
+// sameTableDefinition compares two CREATE TABLE statements ignoring the way
+// the table name is written.
+func sameTableDefinition(a, b string) bool {
+	ta, tb := tokenizeSQL(a), tokenizeSQL(b)
+	ia := slices.IndexFunc(ta, func(token sqlToken) bool { return token.text == "(" })
+	ib := slices.IndexFunc(tb, func(token sqlToken) bool { return token.text == "(" })
+	if ia < 0 || ib < 0 {
+		return sameTokens(ta, tb)
+	}
+	return sameTokens(ta[ia:], tb[ib:])
+}
+
+func sameSQL(a, b string) bool {
+	return sameTokens(tokenizeSQL(a), tokenizeSQL(b))
+}
+
+func sameTokens(a, b []sqlToken) bool {
+	return slices.EqualFunc(a, b, func(x, y sqlToken) bool {
+		return x.text == y.text
+	})
+}
+
+type tableElement struct {
+	tokens     []sqlToken
+	raw        string
+	constraint bool
+}
+
+// splitTableDefinition splits a CREATE TABLE statement into its column and
+// constraint definitions and the tokens following the closing parenthesis.
+func splitTableDefinition(sql string) ([]tableElement, []sqlToken, bool) {
+	tokens := tokenizeSQL(sql)
+	open := slices.IndexFunc(tokens, func(token sqlToken) bool { return token.text == "(" })
+	if open < 0 {
+		return nil, nil, false
+	}
+
+	var elements []tableElement
+	depth := 0
+	start := open + 1
+	for i := open; i < len(tokens); i++ {
+		switch tokens[i].text {
+		case "(":
+			depth++
+		case ")":
+			depth--
+		}
+		if (depth == 1 && tokens[i].text == ",") || depth == 0 {
+			if start == i {
+				return nil, nil, false
+			}
+			part := tokens[start:i]
+			switch part[0].text {
+			case "CONSTRAINT", "PRIMARY", "UNIQUE", "CHECK", "FOREIGN":
+				elements = append(elements, tableElement{tokens: part, raw: sql[part[0].start:part[len(part)-1].end], constraint: true})
+			default:
+				elements = append(elements, tableElement{tokens: part, raw: sql[part[0].start:part[len(part)-1].end]})
+			}
+			start = i + 1
+		}
+		if depth == 0 {
+			return elements, tokens[i+1:], true
+		}
+	}
+	return nil, nil, false
+}
+
+// addedColumns reports whether b only differs from a by columns appended after
+// the existing ones that ALTER TABLE ADD COLUMN can add, and returns their
+// definitions.
+func addedColumns(a, b string) ([]string, bool) {
+	elementsA, suffixA, ok := splitTableDefinition(a)
+	if !ok {
+		return nil, false
+	}
+	elementsB, suffixB, ok := splitTableDefinition(b)
+	if !ok || !sameTokens(suffixA, suffixB) {
+		return nil, false
+	}
+
+	isColumn := func(e tableElement) bool { return !e.constraint }
+	columnsA := slices.DeleteFunc(slices.Clone(elementsA), func(e tableElement) bool { return e.constraint })
+	columnsB := slices.DeleteFunc(slices.Clone(elementsB), func(e tableElement) bool { return e.constraint })
+	constraintsA := slices.DeleteFunc(slices.Clone(elementsA), isColumn)
+	constraintsB := slices.DeleteFunc(slices.Clone(elementsB), isColumn)
+
+	sameElement := func(x, y tableElement) bool { return sameTokens(x.tokens, y.tokens) }
+	if len(columnsB) <= len(columnsA) ||
+		!slices.EqualFunc(columnsA, columnsB[:len(columnsA)], sameElement) ||
+		!slices.EqualFunc(constraintsA, constraintsB, sameElement) {
+		return nil, false
+	}
+
+	var result []string
+	for _, column := range columnsB[len(columnsA):] {
+		if !canAddColumn(column.tokens) {
+			return nil, false
+		}
+		result = append(result, column.raw)
+	}
+	return result, true
+}
+
+// canAddColumn applies the restrictions of ALTER TABLE ADD COLUMN to a column
+// definition.
+func canAddColumn(tokens []sqlToken) bool {
+	notNull := false
+	defaultValue := ""
+	for i, token := range tokens {
+		switch token.text {
+		case "PRIMARY", "UNIQUE", "STORED":
+			return false
+		case "NOT":
+			if i+1 < len(tokens) && tokens[i+1].text == "NULL" {
+				notNull = true
+			}
+		case "DEFAULT":
+			if i+1 >= len(tokens) {
+				return false
+			}
+			defaultValue = tokens[i+1].text
+		}
+	}
+	switch defaultValue {
+	case "(", "CURRENT_TIME", "CURRENT_DATE", "CURRENT_TIMESTAMP":
+		return false
+	case "", "NULL":
+		return !notNull
+	}
+	return true
+}
+
+type sqlToken struct {
+	// text is the token normalized for comparison: keywords and identifiers
+	// are upper cased and identifier quotes removed.
+	text       string
+	start, end int
+}
+
+// tokenizeSQL splits sql into tokens, skipping whitespace and comments. It is
+// only meant to compare and split schema statements, not to validate them.
+func tokenizeSQL(sql string) []sqlToken {
+	var tokens []sqlToken
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c >= 0x80 ||
+			(c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+	}
+	// quoted returns the end of a quoted token starting at i, where a doubled
+	// closing character is an escape.
+	quoted := func(i int, closing byte) int {
+		j := i + 1
+		for j < len(sql) {
+			if sql[j] == closing {
+				if closing != ']' && j+1 < len(sql) && sql[j+1] == closing {
+					j += 2
+					continue
+				}
+				return j + 1
+			}
+			j++
+		}
+		return len(sql)
+	}
+
+	for i := 0; i < len(sql); {
+		c := sql[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f':
+			i++
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 1
+			}
+		case strings.HasPrefix(sql[i:], "/*"):
+			end := strings.Index(sql[i+2:], "*/")
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end + 4
+			}
+		case c == '\'':
+			end := quoted(i, '\'')
+			tokens = append(tokens, sqlToken{text: sql[i:end], start: i, end: end})
+			i = end
+		case c == '"' || c == '`' || c == '[':
+			closing := c
+			if c == '[' {
+				closing = ']'
+			}
+			end := quoted(i, closing)
+			content := sql[i+1 : max(i+1, end-1)]
+			content = strings.ReplaceAll(content, string([]byte{closing, closing}), string(closing))
+			tokens = append(tokens, sqlToken{text: strings.ToUpper(content), start: i, end: end})
+			i = end
+		case isWord(c):
+			end := i
+			for end < len(sql) && isWord(sql[end]) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{text: strings.ToUpper(sql[i:end]), start: i, end: end})
+			i = end
+		default:
+			tokens = append(tokens, sqlToken{text: string(c), start: i, end: i + 1})
+			i++
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func openWithSchema(t *testing.T, script string) *goliat.Connection {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	assert.NoError(t, db.ExecScript(script))
+	return db
+}
+
+func applyDiff(t *testing.T, from, to *goliat.Connection) []string {
+	statements, err := goliat.DiffSchema(from, to)
+	assert.NoError(t, err)
+	assert.NoError(t, from.ExecScript(strings.Join(statements, ";\n")+";"))
+
+	remaining, err := goliat.DiffSchema(from, to)
+	assert.NoError(t, err)
+	assert.Empty(t, remaining)
+	return statements
+}
+
+func TestDiffSchemaEqual(t *testing.T) {
+	script := `CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT);
+		CREATE INDEX foo_bar ON foo (bar);`
+	from := openWithSchema(t, script)
+	defer from.Close()
+	to := openWithSchema(t, strings.ReplaceAll(script, " ", "\n  "))
+	defer to.Close()
+
+	statements, err := goliat.DiffSchema(from, to)
+	assert.NoError(t, err)
+	assert.Nil(t, statements)
+}
+
+func TestDiffSchemaAddColumn(t *testing.T) {
+	from := openWithSchema(t, `CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT);
+		CREATE INDEX foo_bar ON foo (bar);
+		CREATE TABLE old (id INTEGER);
+		INSERT INTO foo (bar) VALUES ('a');`)
+	defer from.Close()
+	to := openWithSchema(t, `CREATE TABLE foo (id INTEGER PRIMARY KEY, bar TEXT, baz INTEGER NOT NULL DEFAULT 1);
+		CREATE INDEX foo_bar ON foo (bar, baz);
+		CREATE TABLE qux (id INTEGER PRIMARY KEY);`)
+	defer to.Close()
+
+	statements := applyDiff(t, from, to)
+	assert.Contains(t, statements, `ALTER TABLE "foo" ADD COLUMN baz INTEGER NOT NULL DEFAULT 1`)
+	assert.Contains(t, statements, `DROP TABLE "old"`)
+
+	var bar string
+	var baz int
+	assert.NoError(t, from.QueryRow("SELECT bar, baz FROM foo").Scan(&bar, &baz))
+	assert.Equal(t, "a", bar)
+	assert.Equal(t, 1, baz)
+}
+
+func TestDiffSchemaSqliteLikeNames(t *testing.T) {
+	from := openWithSchema(t, `CREATE TABLE sqlitestats (id INTEGER PRIMARY KEY AUTOINCREMENT);
+		INSERT INTO sqlitestats DEFAULT VALUES;`)
+	defer from.Close()
+	to := openWithSchema(t, `CREATE TABLE SQLiteUsers (id INTEGER PRIMARY KEY);`)
+	defer to.Close()
+
+	statements := applyDiff(t, from, to)
+	assert.Contains(t, statements, `DROP TABLE "sqlitestats"`)
+	assert.Contains(t, statements, `CREATE TABLE SQLiteUsers (id INTEGER PRIMARY KEY)`)
+}
+
+func TestDiffSchemaRebuildTable(t *testing.T) {
+	from := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT, legacy TEXT);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id) ON DELETE CASCADE);
+		CREATE INDEX users_name ON users (name);
+		CREATE VIEW names AS SELECT name FROM users;
+		CREATE TRIGGER users_delete AFTER DELETE ON users BEGIN SELECT 1; END;
+		PRAGMA foreign_keys = ON;
+		INSERT INTO users (id, name, legacy) VALUES (1, 'foo', 'x');
+		INSERT INTO posts (user_id) VALUES (1);`)
+	defer from.Close()
+	to := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL UNIQUE) STRICT;
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id) ON DELETE CASCADE);
+		CREATE INDEX users_name ON users (name);
+		CREATE VIEW names AS SELECT name FROM users;
+		CREATE TRIGGER users_delete AFTER DELETE ON users BEGIN SELECT 1; END;`)
+	defer to.Close()
+
+	statements := applyDiff(t, from, to)
+	assert.Equal(t, "PRAGMA foreign_keys = OFF", statements[0])
+	assert.Equal(t, "PRAGMA foreign_keys = ON", statements[len(statements)-1])
+	enabled, err := from.Pragmas().ForeignKeys()
+	assert.NoError(t, err)
+	assert.True(t, enabled)
+
+	var name string
+	assert.NoError(t, from.QueryRow("SELECT name FROM names").Scan(&name))
+	assert.Equal(t, "foo", name)
+	var posts int
+	assert.NoError(t, from.QueryRow("SELECT COUNT(*) FROM posts").Scan(&posts))
+	assert.Equal(t, 1, posts)
+
+	schema, err := from.Schema()
+	assert.NoError(t, err)
+	assert.True(t, schema.Table("users").Strict)
+	assert.Len(t, schema.Table("users").Columns, 2)
+	assert.Len(t, schema.Triggers, 1)
+}
+
+func TestDiffSchemaForeignKeysOff(t *testing.T) {
+	from := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT);`)
+	defer from.Close()
+	to := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY, name TEXT NOT NULL);`)
+	defer to.Close()
+
+	statements := applyDiff(t, from, to)
+	assert.NotContains(t, statements, "PRAGMA foreign_keys = ON")
+	enabled, err := from.Pragmas().ForeignKeys()
+	assert.NoError(t, err)
+	assert.False(t, enabled)
+}
+
+func TestDiffSchemaForeignKeyViolation(t *testing.T) {
+	from := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER);
+		PRAGMA foreign_keys = ON;
+		INSERT INTO posts (user_id) VALUES (1);`)
+	defer from.Close()
+	to := openWithSchema(t, `CREATE TABLE users (id INTEGER PRIMARY KEY);
+		CREATE TABLE posts (id INTEGER PRIMARY KEY, user_id INTEGER REFERENCES users (id));`)
+	defer to.Close()
+
+	statements, err := goliat.DiffSchema(from, to)
+	assert.NoError(t, err)
+	err = from.ExecScript(strings.Join(statements, ";\n") + ";")
+	assert.ErrorContains(t, err, "FOREIGN KEY constraint failed")
+
+	// The rebuild is rolled back.
+	schema, err := from.Schema()
+	assert.NoError(t, err)
+	assert.Empty(t, schema.Table("posts").ForeignKeys)
+}
@@ -0,0 +1,111 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#cgo CFLAGS: -DSQLITE_ENABLE_SNAPSHOT
+#include "sqlite3.h"
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+type snapshotHandle struct {
+	ptr *C.sqlite3_snapshot
+}
+
+func (h *snapshotHandle) close() {
+	if h.ptr != nil {
+		C.sqlite3_snapshot_free(h.ptr)
+		h.ptr = nil
+	}
+}
+
+// Snapshot identifies a state of a database in WAL mode. Read transactions on
+// other connections to the same database can be started on it with
+// BeginSnapshotTransaction, as long as the WAL has not been checkpointed past
+// it.
+type Snapshot struct {
+	h *snapshotHandle
+}
+
+func (s *Snapshot) Close() {
+	s.h.close()
+}
+
+// Compare orders s and other by age. It returns a negative value if s is older
+// than other, zero if they are the same and a positive value if s is newer.
+// Both snapshots must be of the same database and the result is only
+// meaningful while its WAL has not been restarted.
+func (s *Snapshot) Compare(other *Snapshot) int {
+	return int(C.sqlite3_snapshot_cmp(s.h.ptr, other.h.ptr))
+}
+
+// Snapshot returns the state of the given database seen by the transaction,
+// which must not have written anything. The read transaction is started if
+// no statement has read from the database yet.
+func (t *Transaction) Snapshot(databaseName DatabaseName) (*Snapshot, error) {
+	var count int
+	err := t.db.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s.sqlite_schema", quoteIdentifier(string(databaseName)))).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+
+	databaseNameRaw := newDatabaseString(string(databaseName))
+	defer databaseNameRaw.Close()
+
+	handle := &snapshotHandle{}
+	ec := C.sqlite3_snapshot_get(t.db.h.ptr, databaseNameRaw.h.ptr, &handle.ptr)
+	if ec != C.SQLITE_OK {
+		return nil, t.db.newDatabaseError()
+	}
+	result := &Snapshot{h: handle}
+	runtime.AddCleanup(result, func(h *snapshotHandle) {
+		h.close()
+	}, result.h)
+	return result, nil
+}
+
+// BeginSnapshotTransaction starts a read transaction that sees the given
+// database as it was when snapshot was taken. It fails if the WAL has been
+// checkpointed past the snapshot.
+func (d *Connection) BeginSnapshotTransaction(databaseName DatabaseName, snapshot *Snapshot) (*Transaction, error) {
+	// sqlite3_snapshot_open needs the connection to know that the database
+	// is in WAL mode, which requires having read it once.
+	var count int
+	err := d.QueryRow(fmt.Sprintf("SELECT COUNT(*) FROM %s.sqlite_schema", quoteIdentifier(string(databaseName)))).Scan(&count)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := d.BeginTransaction()
+	if err != nil {
+		return nil, err
+	}
+
+	databaseNameRaw := newDatabaseString(string(databaseName))
+	defer databaseNameRaw.Close()
+
+	ec := C.sqlite3_snapshot_open(d.h.ptr, databaseNameRaw.h.ptr, snapshot.h.ptr)
+	if ec != C.SQLITE_OK {
+		err := newDatabaseError(ErrorCode(ec), "failed to open snapshot")
+		return nil, errors.Join(err, tx.Rollback())
+	}
+	return tx, nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSnapshot(t *testing.T) {
+	writer, path := openWAL(t)
+	defer writer.Close()
+	assert.NoError(t, writer.SetAutoCheckpoint(0))
+	assert.NoError(t, writer.Exec("CREATE TABLE foo (bar INTEGER)"))
+	assert.NoError(t, writer.Exec("INSERT INTO foo VALUES (1)"))
+
+	first, err := goliat.Open(path)
+	assert.NoError(t, err)
+	defer first.Close()
+	second, err := goliat.Open(path)
+	assert.NoError(t, err)
+	defer second.Close()
+
+	tx, err := first.BeginTransaction()
+	assert.NoError(t, err)
+	snapshot, err := tx.Snapshot(goliat.DatabaseNameMain)
+	assert.NoError(t, err)
+	defer snapshot.Close()
+	assert.Equal(t, 0, snapshot.Compare(snapshot))
+
+	assert.NoError(t, writer.Exec("INSERT INTO foo VALUES (2)"))
+
+	other, err := second.BeginSnapshotTransaction(goliat.DatabaseNameMain, snapshot)
+	assert.NoError(t, err)
+	var count int
+	assert.NoError(t, second.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 1, count)
+	assert.NoError(t, other.Rollback())
+
+	latest, err := second.BeginTransaction()
+	assert.NoError(t, err)
+	newer, err := latest.Snapshot(goliat.DatabaseNameMain)
+	assert.NoError(t, err)
+	defer newer.Close()
+	assert.Less(t, snapshot.Compare(newer), 0)
+	assert.Greater(t, newer.Compare(snapshot), 0)
+	assert.NoError(t, second.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 2, count)
+	assert.NoError(t, latest.Rollback())
+	assert.NoError(t, tx.Rollback())
+
+	// A snapshot cannot be opened once the WAL was checkpointed past it.
+	_, _, err = writer.Checkpoint(goliat.DatabaseNameMain, goliat.CheckpointModeTruncate)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Exec("INSERT INTO foo VALUES (3)"))
+	_, err = second.BeginSnapshotTransaction(goliat.DatabaseNameMain, snapshot)
+	assert.Error(t, err)
+	assert.NoError(t, second.Exec("BEGIN"))
+	assert.NoError(t, second.Exec("ROLLBACK"))
+}
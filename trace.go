@@ -0,0 +1,114 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+#include <stdint.h>
+
+extern int goliatTraceCallback(unsigned int, void*, void*, void*);
+
+static inline int goliat_trace_v2(sqlite3 *db, unsigned int mask, uintptr_t handle) {
+	return sqlite3_trace_v2(db, mask, mask ? goliatTraceCallback : 0, (void*)handle);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"log/slog"
+	"runtime/cgo"
+	"time"
+	"unsafe"
+)
+
+// TraceMask selects the events reported by Connection.SetTrace.
+type TraceMask uint
+
+const (
+	// TraceMaskStmt is reported when a statement starts running.
+	TraceMaskStmt TraceMask = C.SQLITE_TRACE_STMT
+	// TraceMaskProfile is reported when a statement finishes, with its run time.
+	TraceMaskProfile TraceMask = C.SQLITE_TRACE_PROFILE
+	// TraceMaskRow is reported for each row produced by a statement.
+	TraceMaskRow TraceMask = C.SQLITE_TRACE_ROW
+	// TraceMaskClose is reported when the connection closes.
+	TraceMaskClose TraceMask = C.SQLITE_TRACE_CLOSE
+)
+
+type TraceEvent struct {
+	Type TraceMask
+	// SQL is the statement text as prepared. Empty for TraceMaskClose.
+	SQL string
+	// ExpandedSQL is the statement text with bound parameters expanded.
+	// Only set for TraceMaskStmt and TraceMaskProfile.
+	ExpandedSQL string
+	// Elapsed is the statement run time. Only set for TraceMaskProfile.
+	Elapsed time.Duration
+}
+
+// SetTrace registers fn to be called for the events selected by mask.
+// Passing a zero mask or a nil fn disables tracing.
+func (d *Connection) SetTrace(mask TraceMask, fn func(TraceEvent)) error {
+	var handle C.uintptr_t
+	if mask == 0 || fn == nil {
+		mask = 0
+		defer d.h.replaceCallback("trace", nil)
+	} else {
+		handle = d.h.replaceCallback("trace", fn)
+	}
+	if ec := C.goliat_trace_v2(d.h.ptr, C.uint(mask), handle); ec != C.SQLITE_OK {
+		return d.newDatabaseError()
+	}
+	return nil
+}
+
+//export goliatTraceCallback
+func goliatTraceCallback(mask C.uint, ctx unsafe.Pointer, p unsafe.Pointer, x unsafe.Pointer) C.int {
+	fn := cgo.Handle(uintptr(ctx)).Value().(func(TraceEvent))
+	event := TraceEvent{Type: TraceMask(mask)}
+
+	if event.Type != TraceMaskClose {
+		stmt := (*C.sqlite3_stmt)(p)
+		event.SQL = C.GoString(C.sqlite3_sql(stmt))
+		if event.Type == TraceMaskStmt || event.Type == TraceMaskProfile {
+			if expanded := C.sqlite3_expanded_sql(stmt); expanded != nil {
+				event.ExpandedSQL = C.GoString(expanded)
+				C.sqlite3_free(unsafe.Pointer(expanded))
+			}
+		}
+		if event.Type == TraceMaskProfile {
+			event.Elapsed = time.Duration(*(*C.sqlite3_int64)(x))
+		}
+	}
+
+	fn(event)
+	return 0
+}
+
+// SlowQueryLogger returns a trace function that logs statements running for
+// at least threshold on logger. Register it with TraceMaskProfile:
+//
+//	db.SetTrace(goliat.TraceMaskProfile, goliat.SlowQueryLogger(logger, 100*time.Millisecond))
+func SlowQueryLogger(logger *slog.Logger, threshold time.Duration) func(TraceEvent) {
+	return func(event TraceEvent) {
+		if event.Type != TraceMaskProfile || event.Elapsed < threshold {
+			return
+		}
+		logger.LogAttrs(context.Background(), slog.LevelWarn, "slow query",
+			slog.String("sql", event.ExpandedSQL),
+			slog.Duration("elapsed", event.Elapsed))
+	}
+}
@@ -0,0 +1,119 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetTrace(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+
+	var events []goliat.TraceEvent
+	err = db.SetTrace(goliat.TraceMaskStmt|goliat.TraceMaskProfile|goliat.TraceMaskRow|goliat.TraceMaskClose, func(event goliat.TraceEvent) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+
+	err = db.Exec("CREATE TABLE foo (bar TEXT)")
+	assert.NoError(t, err)
+	events = nil
+
+	var bar string
+	err = db.QueryRow("SELECT 'baz' WHERE ? = 1", 1).Scan(&bar)
+	assert.NoError(t, err)
+
+	assert.Len(t, events, 3)
+	assert.Equal(t, goliat.TraceMaskStmt, events[0].Type)
+	assert.Equal(t, "SELECT 'baz' WHERE ? = 1", events[0].SQL)
+	assert.Equal(t, "SELECT 'baz' WHERE 1 = 1", events[0].ExpandedSQL)
+	assert.Equal(t, goliat.TraceMaskRow, events[1].Type)
+	assert.Equal(t, goliat.TraceMaskProfile, events[2].Type)
+	assert.Equal(t, "SELECT 'baz' WHERE 1 = 1", events[2].ExpandedSQL)
+
+	events = nil
+	assert.NoError(t, db.Close())
+	assert.Len(t, events, 1)
+	assert.Equal(t, goliat.TraceMaskClose, events[0].Type)
+}
+
+func TestSetTraceDisable(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	calls := 0
+	err = db.SetTrace(goliat.TraceMaskStmt, func(event goliat.TraceEvent) {
+		calls++
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("SELECT 1"))
+	assert.Equal(t, 1, calls)
+
+	assert.NoError(t, db.SetTrace(0, nil))
+	assert.NoError(t, db.Exec("SELECT 1"))
+	assert.Equal(t, 1, calls)
+}
+
+func TestSlowQueryLogger(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+
+	err = db.SetTrace(goliat.TraceMaskProfile, goliat.SlowQueryLogger(logger, 0))
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("SELECT ?", "baz"))
+	assert.Contains(t, buf.String(), "slow query")
+	assert.Contains(t, buf.String(), "SELECT 'baz'")
+
+	buf.Reset()
+	err = db.SetTrace(goliat.TraceMaskProfile, goliat.SlowQueryLogger(logger, time.Hour))
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("SELECT 1"))
+	assert.Empty(t, buf.String())
+}
+
+func TestSetTraceCloseWithOpenStatement(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+
+	var events []goliat.TraceEvent
+	err = db.SetTrace(goliat.TraceMaskClose|goliat.TraceMaskProfile, func(event goliat.TraceEvent) {
+		events = append(events, event)
+	})
+	assert.NoError(t, err)
+
+	stmt, err := db.Prepare("SELECT 1 UNION ALL SELECT 2")
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.ROW, stmt.Step())
+	assert.NoError(t, db.Close())
+
+	// Finalizing the statement frees the zombie connection, which must not
+	// call the released trace callback.
+	assert.NotPanics(t, func() {
+		assert.NoError(t, stmt.Close())
+	})
+	assert.Empty(t, events)
+}
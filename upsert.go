@@ -0,0 +1,161 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Upsert inserts a row with the given column values or, if it conflicts on
+// conflictColumns, updates the other columns of the existing row with them.
+// The statement has already run when Upsert returns; the iterator yields the
+// resulting row with all its columns, or no row when every column is a
+// conflict column and the row already existed.
+func (d *Connection) Upsert(table string, conflictColumns []string, values map[string]any) (*QueryIterator, error) {
+	columns := slices.Sorted(maps.Keys(values))
+	args := make([]any, len(columns))
+	for i, column := range columns {
+		args[i] = values[column]
+	}
+	return d.upsert(table, conflictColumns, columns, args)
+}
+
+// UpsertStruct is like Upsert with the column values taken from the exported
+// fields of value, a struct or a pointer to a struct. Columns are named after
+// the `goliat` tag of each field, or the field name when there is none. Fields
+// tagged "-" are skipped and nil pointer fields are stored as NULL.
+//
+// The fields of untagged embedded structs of exported types are promoted as
+// in encoding/json:
+// a field hides the ones with the same name nested deeper, fields with the
+// same name at the same depth are all skipped and so are the fields of a nil
+// embedded pointer.
+func (d *Connection) UpsertStruct(table string, conflictColumns []string, value any) (*QueryIterator, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer && !v.IsNil() {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("upsert: expected a struct, got %T", value)
+	}
+
+	fields := upsertFields(v, 0, nil)
+	var columns []string
+	var args []any
+	for i, field := range fields {
+		hidden := false
+		for j, other := range fields {
+			if j != i && other.name == field.name && other.depth <= field.depth {
+				hidden = true
+				break
+			}
+		}
+		if !hidden {
+			columns = append(columns, field.name)
+			args = append(args, field.value)
+		}
+	}
+	return d.upsert(table, conflictColumns, columns, args)
+}
+
+type upsertField struct {
+	name  string
+	value any
+	depth int
+}
+
+var bindHandlerType = reflect.TypeFor[BindHandler]()
+
+// upsertFields appends the column values of the fields of v, a struct nested
+// depth embedded structs deep, to fields.
+func upsertFields(v reflect.Value, depth int, fields []upsertField) []upsertField {
+	for i := range v.NumField() {
+		field := v.Type().Field(i)
+		name := field.Tag.Get("goliat")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		fieldValue := v.Field(i)
+		if field.Anonymous && name == "" && !field.Type.Implements(bindHandlerType) {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Pointer && embedded.Type().Elem().Kind() == reflect.Struct {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				fields = upsertFields(embedded, depth+1, fields)
+				continue
+			}
+		}
+		if name == "" {
+			name = field.Name
+		}
+		if fieldValue.Kind() == reflect.Pointer {
+			if fieldValue.IsNil() {
+				fields = append(fields, upsertField{name: name, depth: depth})
+				continue
+			}
+			if _, ok := fieldValue.Interface().(BindHandler); !ok {
+				fieldValue = fieldValue.Elem()
+			}
+		}
+		fields = append(fields, upsertField{name: name, value: fieldValue.Interface(), depth: depth})
+	}
+	return fields
+}
+
+func (d *Connection) upsert(table string, conflictColumns []string, columns []string, args []any) (*QueryIterator, error) {
+	if len(columns) == 0 {
+		return nil, errors.New("upsert: no columns")
+	}
+	if len(conflictColumns) == 0 {
+		return nil, errors.New("upsert: no conflict columns")
+	}
+
+	quoted := make([]string, len(columns))
+	var updates []string
+	for i, column := range columns {
+		quoted[i] = quoteIdentifier(column)
+		if !slices.Contains(conflictColumns, column) {
+			updates = append(updates, fmt.Sprintf("%s = excluded.%s", quoted[i], quoted[i]))
+		}
+	}
+	conflict := make([]string, len(conflictColumns))
+	for i, column := range conflictColumns {
+		if !slices.Contains(columns, column) {
+			return nil, fmt.Errorf("upsert: no value for conflict column %s", column)
+		}
+		conflict[i] = quoteIdentifier(column)
+	}
+
+	action := "NOTHING"
+	if len(updates) > 0 {
+		action = "UPDATE SET " + strings.Join(updates, ", ")
+	}
+	sql := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s) ON CONFLICT (%s) DO %s RETURNING *",
+		quoteIdentifier(table),
+		strings.Join(quoted, ", "),
+		strings.Repeat(", ?", len(columns))[2:],
+		strings.Join(conflict, ", "),
+		action)
+	return d.ExecReturning(sql, args...)
+}
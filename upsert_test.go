@@ -0,0 +1,147 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpsert(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE, name TEXT, visits INTEGER DEFAULT 0)"))
+
+	rows, err := db.Upsert("users", []string{"email"}, map[string]any{"email": "foo@example.com", "name": "Foo"})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"id", "email", "name", "visits"}, rows.Columns())
+	assert.True(t, rows.Next())
+	var id, visits int
+	var email, name string
+	assert.NoError(t, rows.Scan(&id, &email, &name, &visits))
+	assert.Equal(t, 1, id)
+	assert.Equal(t, "Foo", name)
+	assert.False(t, rows.Next())
+	assert.NoError(t, rows.Err())
+	rows.Close()
+
+	// The statement runs even if the rows are not read.
+	rows, err = db.Upsert("users", []string{"email"}, map[string]any{"email": "foo@example.com", "name": "Bar"})
+	assert.NoError(t, err)
+	rows.Close()
+	assert.NoError(t, db.QueryRow("SELECT id, name FROM users WHERE email = ?", "foo@example.com").Scan(&id, &name))
+	assert.Equal(t, 1, id)
+	assert.Equal(t, "Bar", name)
+
+	rows, err = db.Upsert("users", []string{"email"}, map[string]any{"email": "foo@example.com"})
+	assert.NoError(t, err)
+	assert.False(t, rows.Next())
+	rows.Close()
+
+	_, err = db.Upsert("users", []string{"email"}, map[string]any{"name": "Baz"})
+	assert.Error(t, err)
+	_, err = db.Upsert("users", []string{"name"}, map[string]any{"name": "Baz"})
+	assert.Error(t, err)
+}
+
+func TestUpsertStruct(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY, email TEXT UNIQUE, name TEXT, nickname TEXT)"))
+
+	type user struct {
+		ID       int    `goliat:"id"`
+		Email    string `goliat:"email"`
+		Name     string `goliat:"name"`
+		Nickname *string
+		Ignored  string `goliat:"-"`
+		internal string
+	}
+
+	nickname := "foo"
+	rows, err := db.UpsertStruct("users", []string{"id"}, &user{ID: 7, Email: "foo@example.com", Name: "Foo", Nickname: &nickname})
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	rows.Close()
+
+	rows, err = db.UpsertStruct("users", []string{"id"}, user{ID: 7, Email: "bar@example.com", Name: "Bar"})
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	var id int
+	var email, name string
+	var nicknameValue []byte
+	assert.NoError(t, rows.Scan(&id, &email, &name, &nicknameValue))
+	assert.Equal(t, "bar@example.com", email)
+	assert.Nil(t, nicknameValue)
+	rows.Close()
+
+	_, err = db.UpsertStruct("users", []string{"id"}, 42)
+	assert.Error(t, err)
+}
+
+type UpsertTimestamps struct {
+	Created string `goliat:"created"`
+	Updated string `goliat:"updated"`
+}
+
+type UpsertOwner struct {
+	Owner string `goliat:"owner"`
+	Name  string `goliat:"name"`
+}
+
+func TestUpsertStructEmbedded(t *testing.T) {
+	db, err := goliat.Open(":memory:")
+	assert.NoError(t, err)
+	defer db.Close()
+	assert.NoError(t, db.Exec("CREATE TABLE posts (id INTEGER PRIMARY KEY, name TEXT, owner TEXT, created TEXT, updated TEXT)"))
+
+	type post struct {
+		ID   int    `goliat:"id"`
+		Name string `goliat:"name"`
+		UpsertTimestamps
+		*UpsertOwner
+	}
+
+	rows, err := db.UpsertStruct("posts", []string{"id"}, post{
+		ID:               1,
+		Name:             "foo",
+		UpsertTimestamps: UpsertTimestamps{Created: "monday", Updated: "tuesday"},
+		UpsertOwner:      &UpsertOwner{Owner: "bar", Name: "hidden"},
+	})
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	var id int
+	var name, owner, created, updated string
+	assert.NoError(t, rows.Scan(&id, &name, &owner, &created, &updated))
+	assert.Equal(t, "foo", name)
+	assert.Equal(t, "bar", owner)
+	assert.Equal(t, "monday", created)
+	assert.Equal(t, "tuesday", updated)
+	rows.Close()
+
+	// The fields of a nil embedded pointer are left out.
+	rows, err = db.UpsertStruct("posts", []string{"id"}, post{ID: 1, Name: "baz"})
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	assert.NoError(t, rows.Scan(&id, &name, &owner, &created, &updated))
+	assert.Equal(t, "baz", name)
+	assert.Equal(t, "bar", owner)
+	assert.Empty(t, created)
+	rows.Close()
+}
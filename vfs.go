@@ -0,0 +1,485 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+#include <stdint.h>
+#include <stdlib.h>
+#include <string.h>
+
+typedef struct goliat_file {
+	sqlite3_file base;
+	uintptr_t handle;
+} goliat_file;
+
+typedef struct goliat_vfs {
+	sqlite3_vfs base;
+	// os is the VFS that was the default one at registration, used for the
+	// methods not related to files.
+	sqlite3_vfs *os;
+} goliat_vfs;
+
+extern int goliatVFSOpen(uintptr_t, char*, goliat_file*, int, int*);
+extern int goliatVFSDelete(uintptr_t, char*, int);
+extern int goliatVFSAccess(uintptr_t, char*, int, int*);
+extern int goliatVFSFullPathname(uintptr_t, char*, int, char*);
+extern int goliatFileClose(uintptr_t);
+extern int goliatFileRead(uintptr_t, void*, int, sqlite3_int64);
+extern int goliatFileWrite(uintptr_t, void*, int, sqlite3_int64);
+extern int goliatFileTruncate(uintptr_t, sqlite3_int64);
+extern int goliatFileSync(uintptr_t, int);
+extern int goliatFileSize(uintptr_t, sqlite3_int64*);
+extern int goliatFileLock(uintptr_t, int);
+extern int goliatFileUnlock(uintptr_t, int);
+extern int goliatFileCheckReservedLock(uintptr_t, int*);
+extern int goliatFileSectorSize(uintptr_t);
+extern int goliatFileDeviceCharacteristics(uintptr_t);
+
+#define GOLIAT_FILE(f) (((goliat_file*)(f))->handle)
+#define GOLIAT_VFS(v) ((uintptr_t)(v)->pAppData)
+#define GOLIAT_OS(v) (((goliat_vfs*)(v))->os)
+
+static inline int goliat_io_close(sqlite3_file *f) { return goliatFileClose(GOLIAT_FILE(f)); }
+static inline int goliat_io_read(sqlite3_file *f, void *buf, int n, sqlite3_int64 off) { return goliatFileRead(GOLIAT_FILE(f), buf, n, off); }
+static inline int goliat_io_write(sqlite3_file *f, const void *buf, int n, sqlite3_int64 off) { return goliatFileWrite(GOLIAT_FILE(f), (void*)buf, n, off); }
+static inline int goliat_io_truncate(sqlite3_file *f, sqlite3_int64 size) { return goliatFileTruncate(GOLIAT_FILE(f), size); }
+static inline int goliat_io_sync(sqlite3_file *f, int flags) { return goliatFileSync(GOLIAT_FILE(f), flags); }
+static inline int goliat_io_file_size(sqlite3_file *f, sqlite3_int64 *size) { return goliatFileSize(GOLIAT_FILE(f), size); }
+static inline int goliat_io_lock(sqlite3_file *f, int level) { return goliatFileLock(GOLIAT_FILE(f), level); }
+static inline int goliat_io_unlock(sqlite3_file *f, int level) { return goliatFileUnlock(GOLIAT_FILE(f), level); }
+static inline int goliat_io_check_reserved_lock(sqlite3_file *f, int *out) { return goliatFileCheckReservedLock(GOLIAT_FILE(f), out); }
+static inline int goliat_io_file_control(sqlite3_file *f, int op, void *arg) { return SQLITE_NOTFOUND; }
+static inline int goliat_io_sector_size(sqlite3_file *f) { return goliatFileSectorSize(GOLIAT_FILE(f)); }
+static inline int goliat_io_device_characteristics(sqlite3_file *f) { return goliatFileDeviceCharacteristics(GOLIAT_FILE(f)); }
+
+static const sqlite3_io_methods goliat_io_methods = {
+	1,
+	goliat_io_close,
+	goliat_io_read,
+	goliat_io_write,
+	goliat_io_truncate,
+	goliat_io_sync,
+	goliat_io_file_size,
+	goliat_io_lock,
+	goliat_io_unlock,
+	goliat_io_check_reserved_lock,
+	goliat_io_file_control,
+	goliat_io_sector_size,
+	goliat_io_device_characteristics,
+};
+
+static inline int goliat_vfs_open(sqlite3_vfs *vfs, sqlite3_filename name, sqlite3_file *f, int flags, int *outFlags) {
+	goliat_file *file = (goliat_file*)f;
+	int resultFlags = flags;
+	file->base.pMethods = 0;
+	file->handle = 0;
+	int rc = goliatVFSOpen(GOLIAT_VFS(vfs), (char*)name, file, flags, &resultFlags);
+	if (rc == SQLITE_OK) {
+		file->base.pMethods = &goliat_io_methods;
+		if (outFlags) {
+			*outFlags = resultFlags;
+		}
+	}
+	return rc;
+}
+
+static inline int goliat_vfs_delete(sqlite3_vfs *vfs, const char *name, int syncDir) { return goliatVFSDelete(GOLIAT_VFS(vfs), (char*)name, syncDir); }
+static inline int goliat_vfs_access(sqlite3_vfs *vfs, const char *name, int flags, int *out) { return goliatVFSAccess(GOLIAT_VFS(vfs), (char*)name, flags, out); }
+static inline int goliat_vfs_full_pathname(sqlite3_vfs *vfs, const char *name, int n, char *out) { return goliatVFSFullPathname(GOLIAT_VFS(vfs), (char*)name, n, out); }
+static inline void *goliat_vfs_dlopen(sqlite3_vfs *vfs, const char *name) { return GOLIAT_OS(vfs)->xDlOpen(GOLIAT_OS(vfs), name); }
+static inline void goliat_vfs_dlerror(sqlite3_vfs *vfs, int n, char *out) { GOLIAT_OS(vfs)->xDlError(GOLIAT_OS(vfs), n, out); }
+static inline void (*goliat_vfs_dlsym(sqlite3_vfs *vfs, void *h, const char *name))(void) { return GOLIAT_OS(vfs)->xDlSym(GOLIAT_OS(vfs), h, name); }
+static inline void goliat_vfs_dlclose(sqlite3_vfs *vfs, void *h) { GOLIAT_OS(vfs)->xDlClose(GOLIAT_OS(vfs), h); }
+static inline int goliat_vfs_randomness(sqlite3_vfs *vfs, int n, char *out) { return GOLIAT_OS(vfs)->xRandomness(GOLIAT_OS(vfs), n, out); }
+static inline int goliat_vfs_sleep(sqlite3_vfs *vfs, int us) { return GOLIAT_OS(vfs)->xSleep(GOLIAT_OS(vfs), us); }
+static inline int goliat_vfs_current_time(sqlite3_vfs *vfs, double *out) { return GOLIAT_OS(vfs)->xCurrentTime(GOLIAT_OS(vfs), out); }
+static inline int goliat_vfs_get_last_error(sqlite3_vfs *vfs, int n, char *out) { return GOLIAT_OS(vfs)->xGetLastError(GOLIAT_OS(vfs), n, out); }
+
+static inline int goliat_vfs_current_time_int64(sqlite3_vfs *vfs, sqlite3_int64 *out) {
+	sqlite3_vfs *os = GOLIAT_OS(vfs);
+	if (os->iVersion >= 2 && os->xCurrentTimeInt64) {
+		return os->xCurrentTimeInt64(os, out);
+	}
+	double now;
+	int rc = os->xCurrentTime(os, &now);
+	*out = (sqlite3_int64)(now * 86400000.0);
+	return rc;
+}
+
+static inline sqlite3_vfs *goliat_vfs_new(const char *name, uintptr_t handle, int maxPathname) {
+	goliat_vfs *vfs = sqlite3_malloc(sizeof(goliat_vfs));
+	if (!vfs) {
+		return 0;
+	}
+	memset(vfs, 0, sizeof(goliat_vfs));
+	vfs->os = sqlite3_vfs_find(0);
+	vfs->base.iVersion = 2;
+	vfs->base.szOsFile = sizeof(goliat_file);
+	vfs->base.mxPathname = maxPathname;
+	vfs->base.zName = name;
+	vfs->base.pAppData = (void*)handle;
+	vfs->base.xOpen = goliat_vfs_open;
+	vfs->base.xDelete = goliat_vfs_delete;
+	vfs->base.xAccess = goliat_vfs_access;
+	vfs->base.xFullPathname = goliat_vfs_full_pathname;
+	vfs->base.xDlOpen = goliat_vfs_dlopen;
+	vfs->base.xDlError = goliat_vfs_dlerror;
+	vfs->base.xDlSym = goliat_vfs_dlsym;
+	vfs->base.xDlClose = goliat_vfs_dlclose;
+	vfs->base.xRandomness = goliat_vfs_randomness;
+	vfs->base.xSleep = goliat_vfs_sleep;
+	vfs->base.xCurrentTime = goliat_vfs_current_time;
+	vfs->base.xGetLastError = goliat_vfs_get_last_error;
+	vfs->base.xCurrentTimeInt64 = goliat_vfs_current_time_int64;
+	return &vfs->base;
+}
+*/
+import "C"
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"runtime/cgo"
+	"sync"
+	"unsafe"
+)
+
+// OpenFlag describes the kind of file SQLite asks a VFS to open and how.
+type OpenFlag int
+
+const (
+	OpenFlagReadOnly      OpenFlag = C.SQLITE_OPEN_READONLY
+	OpenFlagReadWrite     OpenFlag = C.SQLITE_OPEN_READWRITE
+	OpenFlagCreate        OpenFlag = C.SQLITE_OPEN_CREATE
+	OpenFlagDeleteOnClose OpenFlag = C.SQLITE_OPEN_DELETEONCLOSE
+	OpenFlagExclusive     OpenFlag = C.SQLITE_OPEN_EXCLUSIVE
+	OpenFlagMainDB        OpenFlag = C.SQLITE_OPEN_MAIN_DB
+	OpenFlagTempDB        OpenFlag = C.SQLITE_OPEN_TEMP_DB
+	OpenFlagTransientDB   OpenFlag = C.SQLITE_OPEN_TRANSIENT_DB
+	OpenFlagMainJournal   OpenFlag = C.SQLITE_OPEN_MAIN_JOURNAL
+	OpenFlagTempJournal   OpenFlag = C.SQLITE_OPEN_TEMP_JOURNAL
+	OpenFlagSubJournal    OpenFlag = C.SQLITE_OPEN_SUBJOURNAL
+	OpenFlagSuperJournal  OpenFlag = C.SQLITE_OPEN_SUPER_JOURNAL
+	OpenFlagWAL           OpenFlag = C.SQLITE_OPEN_WAL
+)
+
+// AccessFlag is the check requested by VFS.Access.
+type AccessFlag int
+
+const (
+	AccessFlagExists    AccessFlag = C.SQLITE_ACCESS_EXISTS
+	AccessFlagReadWrite AccessFlag = C.SQLITE_ACCESS_READWRITE
+	AccessFlagRead      AccessFlag = C.SQLITE_ACCESS_READ
+)
+
+// LockLevel is a level of the locking protocol SQLite uses on database files.
+type LockLevel int
+
+const (
+	LockLevelNone      LockLevel = C.SQLITE_LOCK_NONE
+	LockLevelShared    LockLevel = C.SQLITE_LOCK_SHARED
+	LockLevelReserved  LockLevel = C.SQLITE_LOCK_RESERVED
+	LockLevelPending   LockLevel = C.SQLITE_LOCK_PENDING
+	LockLevelExclusive LockLevel = C.SQLITE_LOCK_EXCLUSIVE
+)
+
+// SyncFlag is passed to File.Sync.
+type SyncFlag int
+
+const (
+	SyncFlagNormal   SyncFlag = C.SQLITE_SYNC_NORMAL
+	SyncFlagFull     SyncFlag = C.SQLITE_SYNC_FULL
+	SyncFlagDataOnly SyncFlag = C.SQLITE_SYNC_DATAONLY
+)
+
+// DeviceCharacteristic describes guarantees of the storage behind a File,
+// which allow SQLite to skip some work.
+type DeviceCharacteristic int
+
+const (
+	DeviceCharacteristicAtomic              DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC
+	DeviceCharacteristicAtomic512           DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC512
+	DeviceCharacteristicAtomic1K            DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC1K
+	DeviceCharacteristicAtomic2K            DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC2K
+	DeviceCharacteristicAtomic4K            DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC4K
+	DeviceCharacteristicAtomic8K            DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC8K
+	DeviceCharacteristicAtomic16K           DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC16K
+	DeviceCharacteristicAtomic32K           DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC32K
+	DeviceCharacteristicAtomic64K           DeviceCharacteristic = C.SQLITE_IOCAP_ATOMIC64K
+	DeviceCharacteristicSafeAppend          DeviceCharacteristic = C.SQLITE_IOCAP_SAFE_APPEND
+	DeviceCharacteristicSequential          DeviceCharacteristic = C.SQLITE_IOCAP_SEQUENTIAL
+	DeviceCharacteristicUndeletableWhenOpen DeviceCharacteristic = C.SQLITE_IOCAP_UNDELETABLE_WHEN_OPEN
+	DeviceCharacteristicPowersafeOverwrite  DeviceCharacteristic = C.SQLITE_IOCAP_POWERSAFE_OVERWRITE
+	DeviceCharacteristicImmutable           DeviceCharacteristic = C.SQLITE_IOCAP_IMMUTABLE
+	DeviceCharacteristicBatchAtomic         DeviceCharacteristic = C.SQLITE_IOCAP_BATCH_ATOMIC
+)
+
+// VFS is the Go side of an SQLite virtual file system, see RegisterVFS.
+//
+// Errors returned by the methods of VFS and File are reported to SQLite with
+// the code of a *DatabaseError when they wrap one, for example BUSY from
+// File.Lock, and with the IOERR code matching the method otherwise.
+type VFS interface {
+	// Open opens the file name with the given flags and returns it with
+	// the flags actually used. An empty name asks for a temporary file,
+	// always opened with OpenFlagDeleteOnClose.
+	Open(name string, flags OpenFlag) (File, OpenFlag, error)
+	// Delete removes the file name. Missing files are reported by
+	// wrapping fs.ErrNotExist. syncDir asks to make the deletion durable.
+	Delete(name string, syncDir bool) error
+	Access(name string, flags AccessFlag) (bool, error)
+	// FullPathname returns the canonical name of name, which SQLite uses
+	// to identify database files and derive journal names.
+	FullPathname(name string) (string, error)
+}
+
+// File is a file opened by a VFS. SQLite does not use a File from several
+// goroutines at once, but several Files of the same VFS can be used
+// concurrently.
+type File interface {
+	Close() error
+	// ReadAt reads len(p) bytes at off. Reading past the end of the file
+	// returns the bytes available, possibly with io.EOF.
+	ReadAt(p []byte, off int64) (int, error)
+	// WriteAt writes p at off. Writing fewer bytes without an error is
+	// reported as a full disk.
+	WriteAt(p []byte, off int64) (int, error)
+	Truncate(size int64) error
+	Sync(flags SyncFlag) error
+	FileSize() (int64, error)
+	// Lock raises the lock on the file to level, returning a BUSY
+	// *DatabaseError if another connection prevents it.
+	Lock(level LockLevel) error
+	// Unlock lowers the lock on the file to level.
+	Unlock(level LockLevel) error
+	// CheckReservedLock reports whether any connection holds a lock of
+	// level LockLevelReserved or higher.
+	CheckReservedLock() (bool, error)
+	SectorSize() int
+	DeviceCharacteristics() DeviceCharacteristic
+}
+
+// vfsMaxPathname is the maximum length of the names returned by
+// VFS.FullPathname.
+const vfsMaxPathname = 1024
+
+type vfsRegistration struct {
+	vfs    *C.sqlite3_vfs
+	name   *C.char
+	handle cgo.Handle
+}
+
+var vfsRegistry = struct {
+	sync.Mutex
+	registrations map[string]vfsRegistration
+}{registrations: make(map[string]vfsRegistration)}
+
+// RegisterVFS makes vfs available under name, which can then be selected with
+// OpenOptions.VFS. If makeDefault is true the VFS is also used by connections
+// that do not select one.
+//
+// A VFS implemented in Go supports the rollback journal modes. WAL mode also
+// needs shared memory, which is only available with PRAGMA locking_mode set
+// to EXCLUSIVE.
+func RegisterVFS(name string, vfs VFS, makeDefault bool) error {
+	vfsRegistry.Lock()
+	defer vfsRegistry.Unlock()
+	if _, ok := vfsRegistry.registrations[name]; ok {
+		return fmt.Errorf("vfs %q already registered", name)
+	}
+
+	handle := cgo.NewHandle(vfs)
+	nameRaw := C.CString(name)
+	ptr := C.goliat_vfs_new(nameRaw, C.uintptr_t(handle), vfsMaxPathname)
+	if ptr == nil {
+		handle.Delete()
+		C.free(unsafe.Pointer(nameRaw))
+		return newDatabaseError(NOMEM, "failed to allocate vfs")
+	}
+	if ec := C.sqlite3_vfs_register(ptr, C.int(boolToInt(makeDefault))); ec != C.SQLITE_OK {
+		handle.Delete()
+		C.sqlite3_free(unsafe.Pointer(ptr))
+		C.free(unsafe.Pointer(nameRaw))
+		return newDatabaseError(ErrorCode(ec), "failed to register vfs")
+	}
+	vfsRegistry.registrations[name] = vfsRegistration{vfs: ptr, name: nameRaw, handle: handle}
+	return nil
+}
+
+// UnregisterVFS removes a VFS added with RegisterVFS. No connection may be
+// using it.
+func UnregisterVFS(name string) error {
+	vfsRegistry.Lock()
+	defer vfsRegistry.Unlock()
+	registration, ok := vfsRegistry.registrations[name]
+	if !ok {
+		return fmt.Errorf("vfs %q not registered", name)
+	}
+	if ec := C.sqlite3_vfs_unregister(registration.vfs); ec != C.SQLITE_OK {
+		return newDatabaseError(ErrorCode(ec), "failed to unregister vfs")
+	}
+	delete(vfsRegistry.registrations, name)
+	registration.handle.Delete()
+	C.sqlite3_free(unsafe.Pointer(registration.vfs))
+	C.free(unsafe.Pointer(registration.name))
+	return nil
+}
+
+// vfsResult converts the error returned by a VFS or File method to an SQLite
+// result code, using fallback for errors not wrapping a *DatabaseError.
+func vfsResult(err error, fallback C.int) C.int {
+	if err == nil {
+		return C.SQLITE_OK
+	}
+	var dbErr *DatabaseError
+	if errors.As(err, &dbErr) {
+		return C.int(dbErr.Code)
+	}
+	return fallback
+}
+
+func fileFromHandle(handle C.uintptr_t) File {
+	return cgo.Handle(handle).Value().(File)
+}
+
+//export goliatVFSOpen
+func goliatVFSOpen(vfsHandle C.uintptr_t, name *C.char, file *C.goliat_file, flags C.int, outFlags *C.int) C.int {
+	vfs := cgo.Handle(vfsHandle).Value().(VFS)
+	f, resultFlags, err := vfs.Open(C.GoString(name), OpenFlag(flags))
+	if err != nil {
+		return vfsResult(err, C.SQLITE_CANTOPEN)
+	}
+	file.handle = C.uintptr_t(cgo.NewHandle(f))
+	*outFlags = C.int(resultFlags)
+	return C.SQLITE_OK
+}
+
+//export goliatVFSDelete
+func goliatVFSDelete(vfsHandle C.uintptr_t, name *C.char, syncDir C.int) C.int {
+	vfs := cgo.Handle(vfsHandle).Value().(VFS)
+	err := vfs.Delete(C.GoString(name), syncDir != 0)
+	if errors.Is(err, fs.ErrNotExist) {
+		return C.SQLITE_IOERR_DELETE_NOENT
+	}
+	return vfsResult(err, C.SQLITE_IOERR_DELETE)
+}
+
+//export goliatVFSAccess
+func goliatVFSAccess(vfsHandle C.uintptr_t, name *C.char, flags C.int, out *C.int) C.int {
+	vfs := cgo.Handle(vfsHandle).Value().(VFS)
+	ok, err := vfs.Access(C.GoString(name), AccessFlag(flags))
+	*out = C.int(boolToInt(ok))
+	return vfsResult(err, C.SQLITE_IOERR_ACCESS)
+}
+
+//export goliatVFSFullPathname
+func goliatVFSFullPathname(vfsHandle C.uintptr_t, name *C.char, n C.int, out *C.char) C.int {
+	vfs := cgo.Handle(vfsHandle).Value().(VFS)
+	path, err := vfs.FullPathname(C.GoString(name))
+	if err != nil {
+		return vfsResult(err, C.SQLITE_CANTOPEN)
+	}
+	if len(path) >= int(n) {
+		return C.SQLITE_CANTOPEN
+	}
+	buffer := unsafe.Slice((*byte)(unsafe.Pointer(out)), int(n))
+	copy(buffer, path)
+	buffer[len(path)] = 0
+	return C.SQLITE_OK
+}
+
+//export goliatFileClose
+func goliatFileClose(handle C.uintptr_t) C.int {
+	if handle == 0 {
+		return C.SQLITE_OK
+	}
+	err := fileFromHandle(handle).Close()
+	cgo.Handle(handle).Delete()
+	return vfsResult(err, C.SQLITE_IOERR_CLOSE)
+}
+
+//export goliatFileRead
+func goliatFileRead(handle C.uintptr_t, buffer unsafe.Pointer, n C.int, offset C.sqlite3_int64) C.int {
+	p := unsafe.Slice((*byte)(buffer), int(n))
+	read, err := fileFromHandle(handle).ReadAt(p, int64(offset))
+	if read == len(p) {
+		return C.SQLITE_OK
+	}
+	if err != nil && err != io.EOF {
+		return vfsResult(err, C.SQLITE_IOERR_READ)
+	}
+	clear(p[read:])
+	return C.SQLITE_IOERR_SHORT_READ
+}
+
+//export goliatFileWrite
+func goliatFileWrite(handle C.uintptr_t, buffer unsafe.Pointer, n C.int, offset C.sqlite3_int64) C.int {
+	p := unsafe.Slice((*byte)(buffer), int(n))
+	written, err := fileFromHandle(handle).WriteAt(p, int64(offset))
+	if err != nil {
+		return vfsResult(err, C.SQLITE_IOERR_WRITE)
+	}
+	if written < len(p) {
+		return C.SQLITE_FULL
+	}
+	return C.SQLITE_OK
+}
+
+//export goliatFileTruncate
+func goliatFileTruncate(handle C.uintptr_t, size C.sqlite3_int64) C.int {
+	return vfsResult(fileFromHandle(handle).Truncate(int64(size)), C.SQLITE_IOERR_TRUNCATE)
+}
+
+//export goliatFileSync
+func goliatFileSync(handle C.uintptr_t, flags C.int) C.int {
+	return vfsResult(fileFromHandle(handle).Sync(SyncFlag(flags)), C.SQLITE_IOERR_FSYNC)
+}
+
+//export goliatFileSize
+func goliatFileSize(handle C.uintptr_t, out *C.sqlite3_int64) C.int {
+	size, err := fileFromHandle(handle).FileSize()
+	*out = C.sqlite3_int64(size)
+	return vfsResult(err, C.SQLITE_IOERR_FSTAT)
+}
+
+//export goliatFileLock
+func goliatFileLock(handle C.uintptr_t, level C.int) C.int {
+	return vfsResult(fileFromHandle(handle).Lock(LockLevel(level)), C.SQLITE_IOERR_LOCK)
+}
+
+//export goliatFileUnlock
+func goliatFileUnlock(handle C.uintptr_t, level C.int) C.int {
+	return vfsResult(fileFromHandle(handle).Unlock(LockLevel(level)), C.SQLITE_IOERR_UNLOCK)
+}
+
+//export goliatFileCheckReservedLock
+func goliatFileCheckReservedLock(handle C.uintptr_t, out *C.int) C.int {
+	reserved, err := fileFromHandle(handle).CheckReservedLock()
+	*out = C.int(boolToInt(reserved))
+	return vfsResult(err, C.SQLITE_IOERR_CHECKRESERVEDLOCK)
+}
+
+//export goliatFileSectorSize
+func goliatFileSectorSize(handle C.uintptr_t) C.int {
+	return C.int(fileFromHandle(handle).SectorSize())
+}
+
+//export goliatFileDeviceCharacteristics
+func goliatFileDeviceCharacteristics(handle C.uintptr_t) C.int {
+	return C.int(fileFromHandle(handle).DeviceCharacteristics())
+}
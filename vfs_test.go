@@ -0,0 +1,68 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryVFS(t *testing.T) {
+	assert.NoError(t, goliat.RegisterVFS("memory", goliat.NewMemoryVFS(), false))
+	assert.Error(t, goliat.RegisterVFS("memory", goliat.NewMemoryVFS(), false))
+
+	_, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "memory", MustExist: true})
+	assert.Error(t, err)
+
+	first, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "memory"})
+	assert.NoError(t, err)
+	second, err := goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "memory", MustExist: true})
+	assert.NoError(t, err)
+
+	assert.NoError(t, first.Exec("CREATE TABLE foo (bar TEXT)"))
+	assert.NoError(t, first.Exec("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 1000) INSERT INTO foo SELECT 'row' || i FROM n"))
+	var count int
+	assert.NoError(t, second.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 1000, count)
+
+	// A reader holding its shared lock keeps the writer from committing.
+	rows, err := second.Query("SELECT bar FROM foo")
+	assert.NoError(t, err)
+	assert.True(t, rows.Next())
+	err = first.Exec("DELETE FROM foo")
+	var dbErr *goliat.DatabaseError
+	if assert.True(t, errors.As(err, &dbErr)) {
+		assert.Equal(t, goliat.BUSY, dbErr.Code)
+	}
+	rows.Close()
+	assert.NoError(t, first.Exec("DELETE FROM foo"))
+	assert.NoError(t, second.QueryRow("SELECT COUNT(*) FROM foo").Scan(&count))
+	assert.Equal(t, 0, count)
+
+	// Temporary files are served by the VFS too.
+	assert.NoError(t, first.Exec("CREATE TEMP TABLE baz AS WITH RECURSIVE n(value) AS (SELECT 1 UNION ALL SELECT value + 1 FROM n WHERE value < 100) SELECT value FROM n"))
+	assert.NoError(t, first.QueryRow("SELECT SUM(value) FROM baz").Scan(&count))
+	assert.Equal(t, 5050, count)
+
+	assert.NoError(t, first.Close())
+	assert.NoError(t, second.Close())
+	assert.NoError(t, goliat.UnregisterVFS("memory"))
+	assert.Error(t, goliat.UnregisterVFS("memory"))
+	_, err = goliat.OpenWithOptions("/test.db", goliat.OpenOptions{VFS: "memory"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,107 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat
+
+/*
+#include "sqlite3.h"
+#include <stdint.h>
+
+extern int goliatWALHookCallback(void*, sqlite3*, char*, int);
+
+static inline void goliat_wal_hook(sqlite3 *db, uintptr_t handle) {
+	sqlite3_wal_hook(db, handle ? (int(*)(void*, sqlite3*, const char*, int))goliatWALHookCallback : 0, (void*)handle);
+}
+*/
+import "C"
+
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+// CheckpointMode selects how much work a WAL checkpoint does and how it
+// interacts with readers and writers.
+type CheckpointMode int
+
+const (
+	// CheckpointModePassive copies as many frames as possible without
+	// waiting for readers or writers.
+	CheckpointModePassive CheckpointMode = C.SQLITE_CHECKPOINT_PASSIVE
+	// CheckpointModeFull waits for writers and for readers of older frames,
+	// then copies every frame.
+	CheckpointModeFull CheckpointMode = C.SQLITE_CHECKPOINT_FULL
+	// CheckpointModeRestart is like CheckpointModeFull and also waits until
+	// the next writer can restart the WAL from the beginning.
+	CheckpointModeRestart CheckpointMode = C.SQLITE_CHECKPOINT_RESTART
+	// CheckpointModeTruncate is like CheckpointModeRestart and also
+	// truncates the WAL file to zero bytes.
+	CheckpointModeTruncate CheckpointMode = C.SQLITE_CHECKPOINT_TRUNCATE
+)
+
+// Checkpoint runs a WAL checkpoint on the given database, or on all attached
+// databases when databaseName is empty. It returns the number of frames in the
+// WAL and the number of frames copied back into the database, which are both
+// -1 if the database is not in WAL mode. The counts are also returned when the
+// checkpoint fails with BUSY because it could not complete.
+func (d *Connection) Checkpoint(databaseName DatabaseName, mode CheckpointMode) (logFrames, checkpointed int, err error) {
+	var databaseNameRaw *C.char
+	if databaseName != "" {
+		s := newDatabaseString(string(databaseName))
+		defer s.Close()
+		databaseNameRaw = s.h.ptr
+	}
+
+	var nLog, nCkpt C.int
+	ec := C.sqlite3_wal_checkpoint_v2(d.h.ptr, databaseNameRaw, C.int(mode), &nLog, &nCkpt)
+	if ec != C.SQLITE_OK {
+		err = d.newDatabaseError()
+	}
+	return int(nLog), int(nCkpt), err
+}
+
+// SetWALHook registers fn to be called after each transaction commits to a
+// database in WAL mode, with the number of frames in its WAL. A nil fn removes
+// the hook.
+//
+// SQLite implements automatic checkpoints with the same hook, so SetWALHook
+// disables them and SetAutoCheckpoint removes fn.
+func (d *Connection) SetWALHook(fn func(databaseName DatabaseName, pages int)) {
+	if fn == nil {
+		C.goliat_wal_hook(d.h.ptr, 0)
+		d.h.replaceCallback("wal", nil)
+		return
+	}
+	handle := d.h.replaceCallback("wal", fn)
+	C.goliat_wal_hook(d.h.ptr, handle)
+}
+
+// SetAutoCheckpoint makes the connection run a passive checkpoint after a
+// commit leaves at least pages frames in the WAL. A non-positive value
+// disables automatic checkpoints. The default is 1000 pages.
+func (d *Connection) SetAutoCheckpoint(pages int) error {
+	ec := C.sqlite3_wal_autocheckpoint(d.h.ptr, C.int(pages))
+	d.h.replaceCallback("wal", nil)
+	if ec != C.SQLITE_OK {
+		return d.newDatabaseError()
+	}
+	return nil
+}
+
+//export goliatWALHookCallback
+func goliatWALHookCallback(ctx unsafe.Pointer, db *C.sqlite3, databaseName *C.char, pages C.int) C.int {
+	fn := cgo.Handle(uintptr(ctx)).Value().(func(DatabaseName, int))
+	fn(DatabaseName(C.GoString(databaseName)), int(pages))
+	return C.SQLITE_OK
+}
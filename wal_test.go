@@ -0,0 +1,88 @@
+// Copyright 2025 Filippo Cucchetto
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package goliat_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/filcuc/goliat"
+	"github.com/stretchr/testify/assert"
+)
+
+func openWAL(t *testing.T) (*goliat.Connection, string) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	db, err := goliat.Open(path)
+	assert.NoError(t, err)
+	mode, err := db.Pragmas().SetJournalMode(goliat.JournalModeWAL)
+	assert.NoError(t, err)
+	assert.Equal(t, goliat.JournalModeWAL, mode)
+	return db, path
+}
+
+func TestCheckpoint(t *testing.T) {
+	db, path := openWAL(t)
+	defer db.Close()
+	assert.NoError(t, db.SetAutoCheckpoint(0))
+
+	assert.NoError(t, db.Exec("CREATE TABLE foo (bar TEXT)"))
+	assert.NoError(t, db.Exec("INSERT INTO foo VALUES ('baz')"))
+
+	logFrames, checkpointed, err := db.Checkpoint(goliat.DatabaseNameMain, goliat.CheckpointModePassive)
+	assert.NoError(t, err)
+	assert.Greater(t, logFrames, 0)
+	assert.Equal(t, logFrames, checkpointed)
+
+	logFrames, checkpointed, err = db.Checkpoint("", goliat.CheckpointModeTruncate)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, logFrames)
+	assert.Equal(t, 0, checkpointed)
+	info, err := os.Stat(path + "-wal")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), info.Size())
+
+	_, _, err = db.Checkpoint("missing", goliat.CheckpointModePassive)
+	assert.Error(t, err)
+}
+
+func TestWALHook(t *testing.T) {
+	db, _ := openWAL(t)
+	defer db.Close()
+
+	var names []goliat.DatabaseName
+	var pages []int
+	db.SetWALHook(func(databaseName goliat.DatabaseName, n int) {
+		names = append(names, databaseName)
+		pages = append(pages, n)
+	})
+	assert.NoError(t, db.Exec("CREATE TABLE foo (bar TEXT)"))
+	assert.NoError(t, db.Exec("INSERT INTO foo VALUES ('baz')"))
+	assert.Equal(t, []goliat.DatabaseName{goliat.DatabaseNameMain, goliat.DatabaseNameMain}, names)
+	assert.Len(t, pages, 2)
+	assert.Greater(t, pages[1], pages[0])
+
+	db.SetWALHook(nil)
+	assert.NoError(t, db.Exec("INSERT INTO foo VALUES ('qux')"))
+	assert.Len(t, pages, 2)
+
+	db.SetWALHook(func(goliat.DatabaseName, int) { t.Fatal("hook not removed") })
+	assert.NoError(t, db.SetAutoCheckpoint(1))
+	assert.NoError(t, db.Exec("INSERT INTO foo VALUES ('quux')"))
+	// The automatic checkpoint already copied every frame.
+	logFrames, checkpointed, err := db.Checkpoint(goliat.DatabaseNameMain, goliat.CheckpointModePassive)
+	assert.NoError(t, err)
+	assert.Equal(t, logFrames, checkpointed)
+}